@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// AfterShip tracking API (v4) types, as far as parcel can fill them in. Results are written in the shape of the
+// responses to GET /trackings/:slug/:tracking_number and, in batch mode, GET /trackings.
+type (
+	afterShipResponse struct {
+		Meta struct {
+			Code int `json:"code"`
+		} `json:"meta"`
+		Data afterShipData `json:"data"`
+	}
+	afterShipData struct {
+		Tracking  *afterShipTracking  `json:"tracking,omitempty"`
+		Trackings []afterShipTracking `json:"trackings,omitempty"`
+		Count     *int                `json:"count,omitempty"`
+	}
+	afterShipTracking struct {
+		ID                   string                `json:"id"`
+		TrackingNumber       string                `json:"tracking_number"`
+		Slug                 string                `json:"slug"`
+		Title                string                `json:"title"`
+		Note                 string                `json:"note,omitempty"`
+		Active               bool                  `json:"active"`
+		Tag                  string                `json:"tag"`
+		Subtag               string                `json:"subtag"`
+		SubtagMessage        string                `json:"subtag_message"`
+		ExpectedDelivery     *string               `json:"expected_delivery"`
+		ShipmentDeliveryDate *string               `json:"shipment_delivery_date"`
+		LastUpdatedAt        string                `json:"last_updated_at"`
+		Checkpoints          []afterShipCheckpoint `json:"checkpoints"`
+	}
+	afterShipCheckpoint struct {
+		Slug           string    `json:"slug"`
+		CheckpointTime string    `json:"checkpoint_time"`
+		Location       string    `json:"location"`
+		Message        string    `json:"message"`
+		Tag            string    `json:"tag"`
+		Subtag         string    `json:"subtag"`
+		SubtagMessage  string    `json:"subtag_message"`
+		Coordinates    []float64 `json:"coordinates,omitempty"` // latitude, longitude
+	}
+)
+
+// AfterShip delivery status tags
+const (
+	AS_PENDING          = "Pending"
+	AS_INFO_RECEIVED    = "InfoReceived"
+	AS_IN_TRANSIT       = "InTransit"
+	AS_OUT_FOR_DELIVERY = "OutForDelivery"
+	AS_PICKUP           = "AvailableForPickup"
+	AS_EXCEPTION        = "Exception"
+	AS_DELIVERED        = "Delivered"
+)
+
+// MarshalAfterShip renders results as an AfterShip tracking API response, a single tracking if there is one result
+// or a list of them otherwise, so that consumers written for AfterShip can read them.
+func MarshalAfterShip(results []Result, single, pretty bool) ([]byte, error) {
+	var resp afterShipResponse
+	resp.Meta.Code = 200
+	now := time.Now().Format(time.RFC3339)
+	if single && len(results) == 1 {
+		t := afterShipTrackingOf(results[0], now)
+		resp.Data.Tracking = &t
+	} else {
+		resp.Data.Trackings = make([]afterShipTracking, 0, len(results))
+		for _, res := range results {
+			resp.Data.Trackings = append(resp.Data.Trackings, afterShipTrackingOf(res, now))
+		}
+		count := len(results)
+		resp.Data.Count = &count
+	}
+	return marshalJSON(resp, pretty)
+}
+
+func afterShipTrackingOf(res Result, now string) afterShipTracking {
+	slug := strings.ToLower(string(res.Carrier))
+	id := sha256.Sum256([]byte(string(res.Carrier) + " " + res.TrackingNum))
+	t := afterShipTracking{
+		ID:             hex.EncodeToString(id[:16]),
+		TrackingNumber: res.TrackingNum,
+		Slug:           slug,
+		Title:          res.Label,
+		Note:           res.Note,
+		Active:         !res.Delivered,
+		LastUpdatedAt:  now,
+		Checkpoints:    make([]afterShipCheckpoint, 0, len(res.Updates)),
+	}
+	// AfterShip lists checkpoints oldest first
+	for i := len(res.Updates) - 1; i >= 0; i-- {
+		u := res.Updates[i]
+		tag := afterShipTag(u.Status)
+		c := afterShipCheckpoint{
+			Slug:           slug,
+			CheckpointTime: u.DateTime,
+			Location:       u.Location,
+			Message:        u.Status,
+			Tag:            tag,
+			Subtag:         tag + "_001",
+			SubtagMessage:  u.Status,
+		}
+		if pos, ok := locate(u); ok {
+			c.Coordinates = []float64{pos.Latitude, pos.Longitude}
+		}
+		t.Checkpoints = append(t.Checkpoints, c)
+	}
+
+	switch {
+	case res.Error != nil:
+		t.Tag, t.SubtagMessage = AS_PENDING, res.Error.Message
+	case res.Delivered:
+		t.Tag = AS_DELIVERED
+	case res.State == NOT_FOUND:
+		t.Tag = AS_PENDING
+	case res.State == PRE_TRANSIT:
+		t.Tag = AS_INFO_RECEIVED
+	case len(t.Checkpoints) > 0:
+		t.Tag = t.Checkpoints[len(t.Checkpoints)-1].Tag
+	default:
+		t.Tag = AS_IN_TRANSIT
+	}
+	t.Subtag = t.Tag + "_001"
+	if t.Title == "" {
+		t.Title = res.TrackingNum
+	}
+	if t.SubtagMessage == "" && len(res.Updates) > 0 {
+		t.SubtagMessage = res.Updates[0].Status
+	}
+	if res.DeliveryDateTime != "" {
+		date := res.DeliveryDateTime
+		if res.Delivered {
+			t.ShipmentDeliveryDate = &date
+		} else {
+			t.ExpectedDelivery = &date
+		}
+	}
+	return t
+}
+
+// afterShipTag classifies a tracking update by AfterShip's delivery status tags.
+func afterShipTag(status string) string {
+	lower := strings.ToLower(status)
+	switch {
+	case IsPreTransit(status):
+		return AS_INFO_RECEIVED
+	case strings.Contains(lower, "out for delivery"):
+		return AS_OUT_FOR_DELIVERY
+	case IsException(status):
+		return AS_EXCEPTION
+	case strings.Contains(lower, "available for pickup"), strings.Contains(lower, "ready for pickup"):
+		return AS_PICKUP
+	case strings.Contains(lower, "delivered"):
+		return AS_DELIVERED
+	}
+	return AS_IN_TRANSIT
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrAmazonCSV = errors.New("not an Amazon order history CSV")
+
+// the cells of the tracking column, e.g. "UPS(1Z999AA10123456784)" or, for several shipments, a list of them
+var amazonTrackingPattern = regexp.MustCompile(`([A-Za-z_ ]*)\(\s*([0-9A-Za-z]+)\s*\)`)
+
+// importAmazon reads tracking numbers from Amazon order history CSVs, either the order reports or the Retail.OrderHistory
+// files from a data request, labeling each with the titles of the items shipped under it. Shipments by Amazon's own
+// delivery network, which can't be tracked, are skipped.
+func importAmazon(args []string) ([]Job, error) {
+	fs := flag.NewFlagSet("import amazon", flag.ExitOnError)
+	since := fs.String("since", "", "only import orders placed on or after this `date` (YYYY-MM-DD)")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return nil, errors.New("no CSV given")
+	}
+	var after time.Time
+	if *since != "" {
+		var err error
+		if after, err = time.ParseInLocation(time.DateOnly, *since, time.Local); err != nil {
+			return nil, err
+		}
+	}
+
+	var jobs []Job
+	for _, path := range fs.Args() {
+		found, err := readAmazonFile(path, after)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		jobs = append(jobs, found...)
+	}
+	return dedupeJobs(jobs), nil
+}
+
+func readAmazonFile(path string, after time.Time) ([]Job, error) {
+	if path == "-" {
+		return readAmazonCSV(os.Stdin, after)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readAmazonCSV(f, after)
+}
+
+func readAmazonCSV(r io.Reader, after time.Time) ([]Job, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	tracking, ok := col["carrier name & tracking number"]
+	if !ok {
+		return nil, ErrAmazonCSV
+	}
+	title, ok := col["title"]
+	if !ok {
+		title, ok = col["product name"]
+	}
+	if !ok {
+		title = -1
+	}
+	order, ok := col["order id"]
+	if !ok {
+		order = -1
+	}
+	date, ok := col["order date"]
+	if !ok {
+		date = -1
+	}
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var jobs []Job
+	index := make(map[string]int) // position in jobs by tracking number
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return jobs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := amazonOrderDate(cell(row, date)); ok && d.Before(after) {
+			continue
+		}
+		for _, m := range amazonTrackingPattern.FindAllStringSubmatch(cell(row, tracking), -1) {
+			num := strings.ToUpper(m[2])
+			carrier, err := ValidateCarrier(strings.ReplaceAll(strings.TrimSpace(m[1]), " ", ""))
+			if err != nil || carrier == ANY {
+				if carrier, ok = DetectCarrier(num); !ok {
+					debug("skipping untrackable shipment", "num", num, "carrier", m[1])
+					continue
+				}
+			}
+			item := cell(row, title)
+			if i, ok := index[num]; ok {
+				if item != "" && !strings.Contains(jobs[i].Label, item) {
+					jobs[i].Label += "; " + item
+				}
+				continue
+			}
+			index[num] = len(jobs)
+			jobs = append(jobs, Job{Num: num, Carrier: carrier, Merchant: "Amazon", OrderID: cell(row, order), Label: item})
+		}
+	}
+}
+
+// amazonOrderDate parses the order dates of both the order reports (01/15/24) and data requests (RFC 3339).
+func amazonOrderDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "01/02/06", "01/02/2006", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return *new(time.Time), false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ANY_STAGGER is the delay between the requests for successive carriers made by trackAny, so that a bare number
+// doesn't send a burst of requests to the source.
+const ANY_STAGGER = 250 * time.Millisecond
+
+// trackAny tracks num with every carrier concurrently and returns the first result, in order of preference, that the
+// source has data for; its Carrier is the carrier that matched. The carrier suggested by the format of num, if any, is
+// preferred and queried first. If no carrier matches, the first error is returned, or else a NOT_FOUND result for ANY.
+// The lookups still outstanding when trackAny returns are canceled.
+func trackAny(ctx context.Context, num string) (Result, error) {
+	order := anyOrder(num)
+	// stops the lookups that are still waiting or in flight once trackAny returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type outcome struct {
+		res Result
+		err error
+	}
+	outcomes := make([]chan outcome, len(order))
+	for i, c := range order {
+		outcomes[i] = make(chan outcome, 1)
+		go func(i int, c Carrier) {
+			select {
+			case <-time.After(time.Duration(i) * ANY_STAGGER):
+			case <-ctx.Done():
+				outcomes[i] <- outcome{err: ctx.Err()}
+				return
+			}
+			res, err := Track(ctx, num, c)
+			outcomes[i] <- outcome{res, err}
+		}(i, c)
+	}
+
+	var notFound Result
+	var firstErr error
+	for i := range outcomes {
+		out := <-outcomes[i]
+		if out.err == nil && out.res.State != NOT_FOUND {
+			info("carrier matched", "num", num, "carrier", order[i])
+			return out.res, nil
+		}
+		debug("carrier did not match", "num", num, "carrier", order[i], "err", out.err)
+		switch {
+		case out.err != nil && firstErr == nil:
+			firstErr = out.err
+		case out.err == nil && notFound.Carrier == "":
+			notFound = out.res
+		}
+	}
+	// a carrier that couldn't be asked might have matched, so its failure outranks the others' NOT_FOUND
+	if firstErr != nil {
+		return *new(Result), firstErr
+	}
+	notFound.Carrier = ANY
+	return notFound, nil
+}
+
+// anyOrder returns the carriers that trackAny tries for num, in order of preference.
+func anyOrder(num string) []Carrier {
+	order := []Carrier{}
+	guess, ok := DetectCarrier(num)
+	if ok {
+		order = append(order, guess)
+	}
+	for _, c := range Carriers {
+		if !ok || c != guess {
+			order = append(order, c)
+		}
+	}
+	return order
+}
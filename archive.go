@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ARCHIVE_CHECK is how often -watch applies -archive-after to the store.
+const ARCHIVE_CHECK = time.Hour
+
+// deliveredAt returns when the shipment was delivered, as reported in its result or, failing that, when the
+// delivery was first seen, if it has been delivered.
+func deliveredAt(sh Shipment) (time.Time, bool) {
+	if !sh.Result.Delivered {
+		return *new(time.Time), false
+	}
+	t, err := time.Parse(time.RFC3339, sh.Result.DeliveryDateTime)
+	if err != nil {
+		t = sh.Checked
+	}
+	return t, !t.IsZero()
+}
+
+// archiveDelivered applies -archive-after and -purge to the history: shipments delivered longer ago than
+// -archive-after are moved to the archive, or deleted with -purge, so that they no longer show up in list or
+// -watch.
+func archiveDelivered() {
+	if History == nil || *retain <= 0 {
+		return
+	}
+	keys, err := History.Archive(context.Background(), time.Now().Add(-*retain), *purge)
+	if err != nil {
+		warn("archiving delivered shipments failed", "err", err)
+		return
+	}
+	if len(keys) > 0 {
+		info("archived delivered shipments", "count", len(keys), "purged", *purge)
+	}
+}
+
+// archivedStore adds the archived shipments to those listed by a Store.
+type archivedStore struct {
+	Store
+}
+
+func (s archivedStore) ListShipments(ctx context.Context) ([]Shipment, error) {
+	list, err := s.Store.ListShipments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.Store.ArchivedShipments(ctx)
+	return append(list, archived...), err
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBatch = errors.New("one or more tracking numbers could not be tracked")
+
+type Job struct {
+	Num     string  `json:"trackingNum"`
+	Carrier Carrier `json:"carrier"`
+	// set by importers that know them
+	Merchant string `json:"merchant,omitempty"`
+	OrderID  string `json:"orderId,omitempty"`
+	Label    string `json:"label,omitempty"` // what was shipped
+}
+
+// ReadJobs reads one tracking number per line from r. A line may name its own carrier after the number, separated
+// by whitespace or a comma; otherwise carrier is used. Blank lines and lines beginning with # are ignored.
+func ReadJobs(r io.Reader, carrier string) ([]Job, error) {
+	var jobs []Job
+	scanner := bufio.NewScanner(r)
+	var line int
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		num, err := SanitizeInput(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c := carrier
+		if len(fields) > 1 {
+			c = fields[1]
+		}
+		cr, err := ValidateCarrier(c)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		jobs = append(jobs, Job{Num: num, Carrier: cr})
+	}
+	return jobs, scanner.Err()
+}
+
+// ReadJobsFile reads jobs from the file at path, or from stdin if path is -.
+func ReadJobsFile(path, carrier string) ([]Job, error) {
+	if path == "-" {
+		return ReadJobs(os.Stdin, carrier)
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return ReadJobs(in, carrier)
+}
+
+// BatchStats summarizes a batch run.
+type BatchStats struct {
+	Queried   int            `json:"queried"`
+	Succeeded int            `json:"succeeded"`
+	Delivered int            `json:"delivered"`
+	NotFound  int            `json:"notFound"`
+	Failed    int            `json:"failed"`
+	Errors    map[string]int `json:"errors"` // failures by error code
+	Seconds   float64        `json:"seconds"`
+	CacheHits int64          `json:"cacheHits"` // lookups answered from the response cache
+	ExitCode  int            `json:"exitCode"`  // the most severe exit code called for by any result
+}
+
+func (s *BatchStats) add(res Result) {
+	s.Queried++
+	s.ExitCode = worseExit(s.ExitCode, ExitCode(res))
+	switch {
+	case res.Error != nil:
+		s.Failed++
+		s.Errors[res.Error.Code]++
+		return
+	case res.State == NOT_FOUND:
+		s.NotFound++
+	case res.Delivered:
+		s.Delivered++
+	}
+	s.Succeeded++
+}
+
+// String formats s as a few lines of text.
+func (s BatchStats) String() string {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "queried %d in %.1fs (%d from cache): %d succeeded (%d delivered, %d not found), %d failed\n",
+		s.Queried, s.Seconds, s.CacheHits, s.Succeeded, s.Delivered, s.NotFound, s.Failed)
+	codes := make([]string, 0, len(s.Errors))
+	for code := range s.Errors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(b, "  %s: %d\n", code, s.Errors[code])
+	}
+	return b.String()
+}
+
+// RunBatch tracks every job, looking up as many as concurrency at once, and writes the results to sinks in the order
+// that they complete. Streaming formats are written as each result completes; json, cloudevents, and ics results are
+// collected and written as a single document. Failed lookups are logged and written as results with an error. The
+// error returned by RunBatch is only for failures to write the output, or ctx being canceled, in which case the
+// remaining jobs are abandoned.
+func RunBatch(ctx context.Context, jobs []Job, sinks Sinks, concurrency int) (stats BatchStats, err error) {
+	stats.Errors = map[string]int{}
+	start := time.Now()
+	var hits int64
+	if Cache != nil {
+		hits = Cache.Hits.Load()
+	}
+	defer func() {
+		stats.Seconds = time.Since(start).Seconds()
+		if Cache != nil {
+			stats.CacheHits = Cache.Hits.Load() - hits
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type outcome struct {
+		job Job
+		res Result
+		err error
+	}
+	todo := make(chan Job)
+	done := make(chan outcome)
+	go func() {
+		defer close(todo)
+		for _, job := range jobs {
+			select {
+			case todo <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < max(concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range todo {
+				res, err := Track(ctx, job.Num, job.Carrier)
+				done <- outcome{job, res, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for out := range done {
+		// keep draining so that the workers can exit
+		if err != nil || ctx.Err() != nil {
+			continue
+		}
+		res := out.res
+		if out.err != nil {
+			warn("tracking failed", "num", out.job.Num, "err", out.err)
+			res = FailedResult(out.job.Num, out.job.Carrier, out.err)
+		} else {
+			if res.State == NOT_FOUND {
+				warn("tracking number updates not found", "num", out.job.Num)
+			}
+			info("tracked", "num", out.job.Num, "carrier", res.Carrier, "state", res.State)
+			onResult(&res, true)
+		}
+		stats.add(res)
+		if err = sinks.Write(res); err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err = ctx.Err(); err != nil {
+		return stats, err
+	}
+
+	if err = sinks.Flush(); err != nil {
+		return stats, err
+	}
+	if stats.Failed > 0 {
+		logErr(ErrBatch.Error())
+	}
+	return stats, nil
+}
+
+const (
+	SUMMARY_TEXT = "text"
+	SUMMARY_JSON = "json"
+	SUMMARY_NONE = "none"
+)
+
+var ErrSummary = errors.New("invalid summary format")
+
+// WriteStats writes stats to w in the given summary format.
+func WriteStats(w io.Writer, stats BatchStats, format string) error {
+	switch format {
+	case SUMMARY_TEXT:
+		_, err := io.WriteString(w, stats.String())
+		return err
+	case SUMMARY_JSON:
+		return json.NewEncoder(w).Encode(stats)
+	case SUMMARY_NONE:
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSummary, format)
+}
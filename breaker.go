@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("the source is failing; not sending requests until the cooldown has passed")
+
+// BreakerTransport stops sending requests after Threshold consecutive failures, failing them with ErrCircuitOpen
+// instead until Cooldown has passed. Then one request is let through as a trial: if it succeeds, requests resume;
+// otherwise the cooldown starts over. A failure is an error other than cancellation, or a response that suggests that
+// the source is blocking us or is down (403, 429, or 5xx).
+type BreakerTransport struct {
+	Next      http.RoundTripper
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int       // consecutive failures
+	openAt   time.Time // when the circuit opened, if failures >= Threshold
+	trial    bool      // a trial request is in flight
+}
+
+func (t *BreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		return nil, ErrCircuitOpen
+	}
+	resp, err := t.Next.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// canceled by us; says nothing about the source
+		t.mu.Lock()
+		t.trial = false
+		t.mu.Unlock()
+	case err != nil, resp.StatusCode == http.StatusForbidden, resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= 500:
+		t.record(false)
+	default:
+		t.record(true)
+	}
+	return resp, err
+}
+
+func (t *BreakerTransport) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failures < t.Threshold {
+		return true
+	}
+	if t.trial || time.Since(t.openAt) < t.Cooldown {
+		return false
+	}
+	t.trial = true
+	return true
+}
+
+func (t *BreakerTransport) record(ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trial = false
+	if ok {
+		if t.failures >= t.Threshold {
+			info("source recovered; resuming requests")
+		}
+		t.failures = 0
+		return
+	}
+	t.failures++
+	if t.failures >= t.Threshold {
+		if t.failures == t.Threshold {
+			warn("source failing; pausing requests", "failures", t.failures, "cooldown", t.Cooldown)
+		}
+		t.openAt = time.Now()
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// CacheTransport answers GET requests from responses saved in Dir less than TTL ago, and saves successful responses
+// from Next there.
+type CacheTransport struct {
+	Next http.RoundTripper
+	Dir  string
+	TTL  time.Duration
+
+	Hits, Misses atomic.Int64
+}
+
+// Cache is the response cache in use, if any.
+var Cache *CacheTransport
+
+// DefaultCacheDir returns the directory that responses are cached in by default.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "parcel", "responses"), nil
+}
+
+// Fresh reports whether t has a response to req that is younger than TTL. A nil CacheTransport has none.
+func (t *CacheTransport) Fresh(req *http.Request) bool {
+	if t == nil || req.Method != http.MethodGet {
+		return false
+	}
+	fi, err := os.Stat(cassettePath(t.Dir, req))
+	return err == nil && time.Since(fi.ModTime()) < t.TTL
+}
+
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Next.RoundTrip(req)
+	}
+	path := cassettePath(t.Dir, req)
+	if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) < t.TTL {
+		if resp, err := loadInteraction(path, req); err == nil {
+			t.Hits.Add(1)
+			debug("cache hit", "url", req.URL.String(), "age", time.Since(fi.ModTime()))
+			return resp, nil
+		}
+	}
+	t.Misses.Add(1)
+
+	// revalidate a stale response if the source gave us the means to
+	stale, _ := loadInteraction(path, req)
+	if stale != nil {
+		etag, modified := stale.Header.Get("ETag"), stale.Header.Get("Last-Modified")
+		if etag != "" || modified != "" {
+			req = req.Clone(req.Context())
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if modified != "" {
+				req.Header.Set("If-Modified-Since", modified)
+			}
+		}
+	}
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		resp.Body.Close()
+		debug("not modified", "url", req.URL.String())
+		now := time.Now()
+		if err = os.Chtimes(path, now, now); err != nil {
+			warn("refreshing cached response failed", "url", req.URL.String(), "err", err)
+		}
+		return stale, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	return saveInteraction(path, req, resp)
+}
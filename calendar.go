@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrCalendar = errors.New("adding calendar events is only supported on macOS and Windows")
+
+// AddToCalendar adds an all-day event on the (estimated) delivery date of res to the system calendar: the calendar
+// named name in macOS Calendar (the first writable calendar if name is empty), or the default Outlook calendar on
+// Windows. An event already created for the same parcel is moved rather than duplicated.
+func AddToCalendar(res Result, name string) error {
+	dt, err := time.Parse(time.RFC3339, res.DeliveryDateTime)
+	if err != nil {
+		return errors.New("no delivery date to add to the calendar")
+	}
+	summary := "Parcel delivery: " + Title(res)
+	return addCalendarEvent(summary, dt, name)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the arguments are passed to the script rather than interpolated into it, and the date is assembled field by field
+// because AppleScript's date parsing depends on the user's locale
+const calendarScript = `on run argv
+	set d to current date
+	set day of d to 1
+	set year of d to (item 1 of argv) as integer
+	set month of d to (item 2 of argv) as integer
+	set day of d to (item 3 of argv) as integer
+	set time of d to 0
+	set s to item 4 of argv
+	tell application "Calendar"
+		if (item 5 of argv) is "" then
+			set cal to first calendar whose writable is true
+		else
+			set cal to calendar (item 5 of argv)
+		end if
+		set existing to (events of cal whose summary is s)
+		if (count of existing) is 0 then
+			make new event at end of events of cal with properties {summary:s, start date:d, end date:d + 1 * days, allday event:true}
+		else
+			set ev to item 1 of existing
+			set start date of ev to d
+			set end date of ev to d + 1 * days
+		end if
+	end tell
+end run`
+
+func addCalendarEvent(summary string, date time.Time, name string) error {
+	cmd := exec.Command("osascript", "-e", calendarScript,
+		strconv.Itoa(date.Year()), strconv.Itoa(int(date.Month())), strconv.Itoa(date.Day()), summary, name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
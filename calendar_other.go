@@ -0,0 +1,9 @@
+//go:build !darwin && !windows
+
+package main
+
+import "time"
+
+func addCalendarEvent(summary string, date time.Time, name string) error {
+	return ErrCalendar
+}
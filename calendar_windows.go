@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the values are passed through the environment to avoid quoting them for PowerShell
+const calendarScript = `$ErrorActionPreference = 'Stop'
+$outlook = New-Object -ComObject Outlook.Application
+$folder = $outlook.GetNamespace('MAPI').GetDefaultFolder(9)
+$start = Get-Date -Year $env:PARCEL_YEAR -Month $env:PARCEL_MONTH -Day $env:PARCEL_DAY -Hour 0 -Minute 0 -Second 0
+$appt = $folder.Items | Where-Object { $_.Subject -eq $env:PARCEL_SUMMARY } | Select-Object -First 1
+if ($appt -eq $null) {
+	$appt = $folder.Items.Add(1)
+	$appt.Subject = $env:PARCEL_SUMMARY
+	$appt.ReminderSet = $false
+	$appt.BusyStatus = 0
+}
+$appt.Start = $start
+$appt.AllDayEvent = $true
+$appt.Save()`
+
+func addCalendarEvent(summary string, date time.Time, _ string) error {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", calendarScript)
+	cmd.Env = append(os.Environ(),
+		"PARCEL_YEAR="+strconv.Itoa(date.Year()),
+		"PARCEL_MONTH="+strconv.Itoa(int(date.Month())),
+		"PARCEL_DAY="+strconv.Itoa(date.Day()),
+		"PARCEL_SUMMARY="+summary,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
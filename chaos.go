@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrChaosFault = errors.New("unknown chaos fault")
+
+// Chaos flags are for exercising retry and alerting setups against a misbehaving upstream. They are left out of the
+// usage message.
+var (
+	chaosRate    = flag.Float64("chaos-rate", 0, "fraction of requests, between 0 and 1, that have a fault injected")
+	chaosFaults  = flag.String("chaos-faults", "latency,429,html", "comma-separated faults to choose from: latency, 429, html")
+	chaosLatency = flag.Duration("chaos-latency", 3*time.Second, "delay added by the latency fault")
+)
+
+// ChaosTransport injects faults into a fraction of the requests made through it.
+type ChaosTransport struct {
+	Next    http.RoundTripper
+	Rate    float64
+	Faults  []string
+	Latency time.Duration
+}
+
+func (t *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.Faults) == 0 || rand.Float64() >= t.Rate {
+		return t.Next.RoundTrip(req)
+	}
+
+	switch fault := t.Faults[rand.Intn(len(t.Faults))]; fault {
+	case "latency":
+		timer := time.NewTimer(t.Latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		return t.Next.RoundTrip(req)
+	case "429":
+		return &http.Response{
+			Status:     "429 Too Many Requests",
+			StatusCode: http.StatusTooManyRequests,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Retry-After": {"60"}, "Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader("chaos: too many requests\n")),
+			Request:    req,
+		}, nil
+	case "html":
+		resp, err := t.Next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// cut the page off at a random point and leave some unterminated markup behind
+		b = append(b[:rand.Intn(len(b)+1)], `<div class="b_focusTextSmall"><table><tr><td>`...)
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		resp.ContentLength = int64(len(b))
+		resp.Header.Del("Content-Length")
+		return resp, nil
+	default:
+		return nil, fmt.Errorf("chaos: unknown fault %q", fault)
+	}
+}
+
+func chaosEnabled() bool {
+	return *chaosRate > 0
+}
+
+func newChaosTransport(next http.RoundTripper) (*ChaosTransport, error) {
+	var faults []string
+	for _, f := range strings.Split(*chaosFaults, ",") {
+		switch f = strings.TrimSpace(f); f {
+		case "":
+		case "latency", "429", "html":
+			faults = append(faults, f)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrChaosFault, f)
+		}
+	}
+	return &ChaosTransport{Next: next, Rate: *chaosRate, Faults: faults, Latency: *chaosLatency}, nil
+}
+
+// usage prints the command-line usage message, omitting the chaos flags.
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(flag.CommandLine.Output())
+	flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "chaos-") {
+			return
+		}
+		visible.Var(f.Value, f.Name, f.Usage)
+		visible.Lookup(f.Name).DefValue = f.DefValue
+	})
+	visible.PrintDefaults()
+}
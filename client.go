@@ -0,0 +1,36 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Client is used for every request to the source. Its transport reuses connections across requests, so batch runs
+// pay for one TLS handshake per host rather than one per request; replace it, or its Transport, to route requests
+// differently.
+var Client = &http.Client{Transport: NewTransport()}
+
+// ServiceClient is used for requests to services other than the source, such as geocoders and notification
+// services, which must not be cached, rate limited, or counted by the circuit breaker along with the source.
+var ServiceClient = &http.Client{Transport: NewTransport(), Timeout: TIMEOUT}
+
+// NewTransport returns the transport that Client starts out with: HTTP/2 where the server supports it, keep-alives,
+// enough idle connections per host for -concurrency, TLS 1.2 or later, and the proxy from the environment.
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// runDelivered implements the delivered command, which prints nothing and exits with status 0 if the shipment has
+// been delivered, or 1 if it hasn't or can't be tracked. Only bad arguments are reported, and exit with status 2, so
+// that scripts can tell them apart from an undelivered shipment.
+func runDelivered(args []string) error {
+	fs := flag.NewFlagSet("delivered", flag.ExitOnError)
+	n := fs.String("n", "", "tracking number")
+	c := fs.String("c", "", "carrier")
+	srcURL := fs.String("url", "", "tracking page `url`, as for the main command; defaults to $PARCEL_URL")
+	fs.Parse(args)
+
+	num, err := SanitizeInput(*n)
+	if err != nil {
+		fatalWith(EXIT_USAGE, err.Error())
+	}
+	carrier, err := ValidateCarrier(*c)
+	if err != nil {
+		fatalWith(EXIT_USAGE, err.Error())
+	}
+	if *srcURL == "" {
+		*srcURL = os.Getenv("PARCEL_URL")
+	}
+	if *srcURL != "" {
+		if err = SetSourceURL(*srcURL); err != nil {
+			fatalWith(EXIT_USAGE, err.Error())
+		}
+	}
+
+	Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	if res, err := Track(context.Background(), num, carrier); err != nil || !res.Delivered {
+		os.Exit(EXIT_ERROR)
+	}
+	return nil
+}
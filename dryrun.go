@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+)
+
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// DryRunTransport prints each request that reaches it instead of sending it.
+type DryRunTransport struct {
+	W io.Writer
+}
+
+func (t *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fmt.Fprintf(t.W, "%s %s\n", req.Method, req.URL)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			fmt.Fprintf(t.W, "%s: %s\n", name, v)
+		}
+	}
+	proxy := "none"
+	if u, err := http.ProxyFromEnvironment(req); err != nil {
+		proxy = err.Error()
+	} else if u != nil {
+		proxy = u.Redacted()
+	}
+	fmt.Fprintf(t.W, "# proxy: %s\n\n", proxy)
+	return nil, ErrDryRun
+}
+
+// DryRun prints the effective configuration followed by the requests that would be sent for each job, one per
+// carrier for a job with ANY. Client must have a DryRunTransport at the end of its transport chain.
+func DryRun(w io.Writer, jobs []Job, config [][2]string) error {
+	for _, kv := range config {
+		fmt.Fprintf(w, "# %-14s %s\n", kv[0]+":", kv[1])
+	}
+	fmt.Fprintln(w)
+	sources := []string{SourceURL}
+	if VerifyURL != "" {
+		sources = append(sources, VerifyURL)
+	}
+	for _, job := range jobs {
+		carriers := []Carrier{job.Carrier}
+		if job.Carrier == ANY {
+			carriers = anyOrder(job.Num)
+		}
+		for _, carrier := range carriers {
+			for _, src := range sources {
+				req, err := NewRequest(context.Background(), sourceURL(src, job.Num, carrier))
+				if err != nil {
+					return err
+				}
+				if _, err = Client.Do(req); err != nil && !errors.Is(err, ErrDryRun) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dryRunConfig describes the configuration selected by the command-line flags and environment.
+func dryRunConfig(f Format, fSet bool, jobs int) [][2]string {
+	format := string(f)
+	if !fSet {
+		format += " (table on a terminal)"
+	}
+	set := func(s string) string {
+		if s == "" {
+			return "no"
+		}
+		return s
+	}
+	key := "no"
+	if os.Getenv("PARCEL_SIGNING_KEY") != "" {
+		key = "yes"
+	}
+	return [][2]string{
+		{"source", SourceURL},
+		{"verify", set(VerifyURL)},
+		{"jobs", fmt.Sprint(jobs)},
+		{"format", format},
+		{"outputs", o.String()},
+		{"append", fmt.Sprint(*appnd)},
+		{"compress", fmt.Sprint(*gz)},
+		{"time zone", TZ.String()},
+		{"timeout", fmt.Sprint(TIMEOUT)},
+		{"save html", set(SaveHTML)},
+		{"record", set(*record)},
+		{"replay", set(*replay)},
+		{"instance id", set(*inst)},
+		{"signing key", key},
+	}
+}
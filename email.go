@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Email is the text of an email message, as far as parcel needs it.
+type Email struct {
+	From    string
+	Subject string
+	Text    string // the text of the text/plain and text/html parts, including the URLs of links
+}
+
+// order numbers, as introduced in shipping confirmations: "Order #112-3456789-0123456", "Order number: W1234567"
+var orderIDPattern = regexp.MustCompile(`(?i)\border\s*(?:number|no\.?|id)?\s*[:#]?\s*([0-9A-Z][0-9A-Z-]{4,30}[0-9A-Z])\b`)
+
+// importEmail reads tracking numbers from saved email messages, such as .eml files, or from a message on stdin if no
+// files are given, so that it can be used as a mail filter.
+func importEmail(args []string) ([]Job, error) {
+	fs := flag.NewFlagSet("import email", flag.ExitOnError)
+	fs.Parse(args)
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var jobs []Job
+	for _, path := range paths {
+		e, err := readEmailFile(path)
+		if err != nil {
+			warn("import failed", "path", path, "err", err)
+			continue
+		}
+		jobs = append(jobs, e.Jobs()...)
+	}
+	return dedupeJobs(jobs), nil
+}
+
+func readEmailFile(path string) (Email, error) {
+	if path == "-" {
+		return ReadEmail(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return *new(Email), err
+	}
+	defer f.Close()
+	return ReadEmail(f)
+}
+
+// Jobs returns the tracking numbers in the email, labeled with its merchant and order ID.
+func (e Email) Jobs() []Job {
+	jobs := ExtractTrackingNumbers(e.Subject + "\n" + e.Text)
+	merchant, order := e.Merchant(), e.OrderID()
+	for i := range jobs {
+		jobs[i].Merchant, jobs[i].OrderID = merchant, order
+	}
+	return jobs
+}
+
+// Merchant returns the name of the sender, or the domain of its address if it has no name.
+func (e Email) Merchant() string {
+	addr, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return ""
+	}
+	if addr.Name != "" {
+		return addr.Name
+	}
+	_, domain, _ := strings.Cut(addr.Address, "@")
+	return domain
+}
+
+// OrderID returns the first order number mentioned in the subject or text, or "" if there is none.
+func (e Email) OrderID() string {
+	for _, m := range orderIDPattern.FindAllStringSubmatch(e.Subject+"\n"+e.Text, -1) {
+		if strings.ContainsAny(m[1], "0123456789") {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// ReadEmail reads a message in Internet Message Format (RFC 5322), such as an .eml file, and extracts its text.
+// Parts that can't be decoded are skipped.
+func ReadEmail(r io.Reader) (Email, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return *new(Email), err
+	}
+	dec := new(mime.WordDecoder)
+	e := Email{From: msg.Header.Get("From"), Subject: msg.Header.Get("Subject")}
+	if s, err := dec.DecodeHeader(e.Subject); err == nil {
+		e.Subject = s
+	}
+	if s, err := dec.DecodeHeader(e.From); err == nil {
+		e.From = s
+	}
+	text := new(strings.Builder)
+	readPart(text, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	e.Text = text.String()
+	return e, nil
+}
+
+// readPart appends the text of a MIME part, recursing into multipart parts.
+func readPart(text *strings.Builder, contentType, encoding string, body io.Reader) {
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			readPart(text, p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p)
+		}
+	case mediaType == "text/plain":
+		b, _ := io.ReadAll(body)
+		text.Write(b)
+		text.WriteString("\n")
+	case mediaType == "text/html":
+		htmlText(text, body)
+	case mediaType == "message/rfc822":
+		if e, err := ReadEmail(body); err == nil {
+			text.WriteString(e.Subject + "\n" + e.Text)
+		}
+	}
+}
+
+// htmlText appends the text of an HTML document, along with the URLs of its links, which often carry tracking
+// numbers that aren't in the text.
+func htmlText(text *strings.Builder, r io.Reader) {
+	z := html.NewTokenizer(r)
+	var skip int // depth inside script and style elements
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			text.WriteString("\n")
+			return
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(bytes.TrimSpace(z.Text()))
+				text.WriteString(" ")
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "script", "style":
+				skip++
+			case "br", "p", "div", "tr", "li", "td":
+				text.WriteString("\n")
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "href" {
+					text.WriteString("\n" + string(val) + "\n")
+				}
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); (string(name) == "script" || string(name) == "style") && skip > 0 {
+				skip--
+			}
+		}
+	}
+}
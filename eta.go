@@ -0,0 +1,53 @@
+package main
+
+import "time"
+
+// ETAConfidence describes how far an estimated delivery date can be trusted.
+type ETAConfidence string
+
+const (
+	ETA_SOURCE    ETAConfidence = "source"    // reported by the source alongside recent scans
+	ETA_HEURISTIC ETAConfidence = "heuristic" // recovered by the fallback text layout, which may have picked up the wrong date
+	ETA_STALE     ETAConfidence = "stale"     // the estimate has passed, or the parcel has not been scanned in STALE_AFTER
+)
+
+// STALE_AFTER is how long a shipment can go without a new scan before its estimated delivery date is considered stale.
+const STALE_AFTER = 72 * time.Hour
+
+// ETAConfidenceOf rates the estimated delivery date of res as of now. It returns the empty string if res has been
+// delivered or has no estimate.
+func ETAConfidenceOf(res Result, now time.Time) ETAConfidence {
+	if res.Delivered || res.DeliveryDateTime == "" {
+		return ""
+	}
+	if eta, err := time.Parse(time.RFC3339, res.DeliveryDateTime); err == nil && now.After(eta.AddDate(0, 0, 1)) {
+		return ETA_STALE
+	}
+	if len(res.Updates) > 0 {
+		if last, err := time.Parse(time.RFC3339, res.Updates[0].DateTime); err == nil && now.Sub(last) > STALE_AFTER {
+			return ETA_STALE
+		}
+	}
+	if res.Layout == "text" {
+		return ETA_HEURISTIC
+	}
+	return ETA_SOURCE
+}
+
+// ETAAccuracyDays returns the number of calendar days from the estimated delivery date eta to the delivery date
+// delivered, both RFC 3339 date-times: positive if the parcel was late, negative if it was early. The dates are
+// compared in their own time zones. It returns false if either date can't be parsed.
+func ETAAccuracyDays(eta, delivered string) (int, bool) {
+	e, err := time.Parse(time.RFC3339, eta)
+	if err != nil {
+		return 0, false
+	}
+	d, err := time.Parse(time.RFC3339, delivered)
+	if err != nil {
+		return 0, false
+	}
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return int(day(d).Sub(day(e)).Hours() / 24), true
+}
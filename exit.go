@@ -0,0 +1,39 @@
+package main
+
+// exit codes
+const (
+	EXIT_OK          = 0
+	EXIT_ERROR       = 1 // any other failure, such as an unwritable output file
+	EXIT_USAGE       = 2 // bad arguments
+	EXIT_NETWORK     = 3 // the source could not be reached or refused the request
+	EXIT_PARSE       = 4 // the tracking page could not be parsed
+	EXIT_NOT_FOUND   = 5 // the source has no tracking data for the number
+	EXIT_UNDELIVERED = 6 // not yet delivered, with -fail-if-undelivered
+)
+
+// FailIfUndelivered makes ExitCode report undelivered shipments.
+var FailIfUndelivered bool
+
+// ExitCode returns the exit code that res calls for.
+func ExitCode(res Result) int {
+	switch {
+	case res.Error != nil && (res.Error.Code == ERR_INTERNAL || res.Error.Code == ERR_DATE):
+		return EXIT_PARSE
+	case res.Error != nil:
+		return EXIT_NETWORK
+	case res.State == NOT_FOUND:
+		return EXIT_NOT_FOUND
+	case !res.Delivered && FailIfUndelivered:
+		return EXIT_UNDELIVERED
+	}
+	return EXIT_OK
+}
+
+// worseExit combines the exit codes of two results: failures outrank missing data, which outranks undelivered
+// shipments.
+func worseExit(a, b int) int {
+	if a == EXIT_OK || (b != EXIT_OK && b < a) {
+		return b
+	}
+	return a
+}
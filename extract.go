@@ -0,0 +1,101 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// allowing the single spaces that labels put between groups
+	upsPattern = regexp.MustCompile(`\b1Z(?: ?[0-9A-Z]){16}\b`)
+	// runs of digits, allowing the single spaces that labels and OCR put between groups
+	digitsPattern = regexp.MustCompile(`\d[\d ]{8,40}\d`)
+)
+
+// ExtractTrackingNumbers finds tracking numbers in free text, such as OCR output or an email body, and guesses their
+// carriers from their formats and check digits. DHL's 10 digit numbers are only recognized if the text mentions DHL,
+// since they are otherwise easily confused with phone and order numbers.
+func ExtractTrackingNumbers(text string) []Job {
+	var jobs []Job
+	seen := make(map[string]bool)
+	add := func(num string, carrier Carrier) {
+		if !seen[num] {
+			seen[num] = true
+			jobs = append(jobs, Job{Num: num, Carrier: carrier})
+		}
+	}
+
+	upper := strings.ToUpper(text)
+	for _, match := range upsPattern.FindAllString(upper, -1) {
+		num := strings.ReplaceAll(match, " ", "")
+		if int(num[17]-'0') == upsCheckDigit(num[2:17]) {
+			add(num, UPS)
+		}
+	}
+
+	mentionsDHL := strings.Contains(upper, "DHL")
+	for _, run := range digitsPattern.FindAllString(text, -1) {
+		num := strings.ReplaceAll(run, " ", "")
+		if carrier, ok := DetectCarrier(num); ok && (carrier != DHL || mentionsDHL) {
+			add(num, carrier)
+			continue
+		}
+		// GS1-128 barcodes on USPS labels prefix the tracking number with 420 and the destination ZIP (or ZIP+4)
+		if strings.HasPrefix(num, "420") {
+			for _, n := range []int{22, 26, 20} {
+				if len(num) > n+7 {
+					if carrier, ok := DetectCarrier(num[len(num)-n:]); ok && carrier == USPS {
+						add(num[len(num)-n:], USPS)
+						break
+					}
+				}
+			}
+		}
+	}
+	return jobs
+}
+
+// DetectCarrier guesses the carrier of a tracking number from its length, prefix, and check digit.
+func DetectCarrier(num string) (Carrier, bool) {
+	if strings.HasPrefix(num, "1Z") {
+		if len(num) == 18 && int(num[17]-'0') == upsCheckDigit(num[2:17]) {
+			return UPS, true
+		}
+		return *new(Carrier), false
+	}
+	if !allDigits(num) {
+		return *new(Carrier), false
+	}
+	check := int(num[len(num)-1] - '0')
+	body := num[:len(num)-1]
+	switch len(num) {
+	case 20, 22, 26:
+		if (num[0] == '9' || strings.HasPrefix(num, "82")) && check == mod10CheckDigit(body) {
+			return USPS, true
+		}
+	case 12:
+		if check == fedexCheckDigit(body) {
+			return FEDEX, true
+		}
+	case 15:
+		if check == mod10CheckDigit(body) {
+			return FEDEX, true
+		}
+	case 10:
+		n, _ := strconv.Atoi(body)
+		if check == n%7 {
+			return DHL, true
+		}
+	}
+	return *new(Carrier), false
+}
+
+func allDigits(s string) bool {
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed facilities.csv
+var facilitiesCSV string
+
+// Facility is a carrier facility that a location string is known to refer to.
+type Facility struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type facilityCode struct {
+	carrier Carrier
+	code    string
+	Facility
+}
+
+// Facilities lists the known facility codes, in the order they are tried.
+var Facilities = loadFacilities(facilitiesCSV)
+
+func loadFacilities(s string) []facilityCode {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic("facilities.csv: " + err.Error())
+	}
+	codes := make([]facilityCode, 0, len(records))
+	for _, rec := range records {
+		lat, err1 := strconv.ParseFloat(rec[3], 64)
+		lon, err2 := strconv.ParseFloat(rec[4], 64)
+		if err1 != nil || err2 != nil {
+			panic("facilities.csv: invalid coordinates for " + rec[1])
+		}
+		codes = append(codes, facilityCode{
+			carrier:  Carrier(rec[0]),
+			code:     normalizeLocation(rec[1]),
+			Facility: Facility{Name: rec[2], Latitude: lat, Longitude: lon},
+		})
+	}
+	return codes
+}
+
+// normalizeLocation upper-cases s, replaces punctuation with spaces, and pads it with spaces, so that codes only
+// match whole words.
+func normalizeLocation(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return ' '
+	}, s)
+	return " " + strings.Join(strings.Fields(s), " ") + " "
+}
+
+// LookupFacility returns the facility that location refers to, if it is known.
+func LookupFacility(carrier Carrier, location string) (Facility, bool) {
+	loc := normalizeLocation(location)
+	for _, f := range Facilities {
+		if (f.carrier == "" || f.carrier == carrier) && strings.Contains(loc, f.code) {
+			return f.Facility, true
+		}
+	}
+	return *new(Facility), false
+}
+
+// EnrichLocations adds the known facilities to the updates of res.
+func EnrichLocations(res *Result) {
+	for i, u := range res.Updates {
+		if f, ok := LookupFacility(res.Carrier, u.Location); ok {
+			res.Updates[i].Facility = &f
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// error codes reported in Result.Error
+const (
+	ERR_TIMEOUT      = "timeout"      // the source did not respond in time
+	ERR_NETWORK      = "network"      // the source could not be reached
+	ERR_RATE_LIMITED = "rate_limited" // the source responded 429 Too Many Requests
+	ERR_REJECTED     = "rejected"     // the source rejected the request with another 4xx status
+	ERR_UPSTREAM     = "upstream"     // the source failed with a 5xx status
+	ERR_NOT_RECORDED = "not_recorded" // -replay has no response for the request
+	ERR_DATE         = "date"         // a date-time could not be parsed, with -strict-dates
+	ERR_SOURCE_DOWN  = "source_down"  // not sent, because the source has been failing
+	ERR_INTERNAL     = "internal"     // anything else, such as an unreadable page
+)
+
+// StatusError is returned by Track when the source responds with a status other than 200 OK.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected response status: " + e.Status
+}
+
+// LookupError describes why a tracking number could not be tracked.
+type LookupError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorCode classifies err, as returned by Track, into one of the ERR_ codes.
+func ErrorCode(err error) string {
+	var se *StatusError
+	var ne net.Error
+	switch {
+	case errors.Is(err, ErrNoRecording):
+		return ERR_NOT_RECORDED
+	case errors.Is(err, ErrDate):
+		return ERR_DATE
+	case errors.Is(err, ErrCircuitOpen):
+		return ERR_SOURCE_DOWN
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return ERR_TIMEOUT
+	case errors.As(err, &se):
+		switch {
+		case se.StatusCode == http.StatusTooManyRequests:
+			return ERR_RATE_LIMITED
+		case se.StatusCode >= 500:
+			return ERR_UPSTREAM
+		}
+		return ERR_REJECTED
+	case errors.As(err, &ne):
+		return ERR_NETWORK
+	}
+	return ERR_INTERNAL
+}
+
+// FailedResult returns the result reported for a tracking number that could not be tracked because of err.
+func FailedResult(num string, carrier Carrier, err error) Result {
+	return Result{
+		TrackingNum: num,
+		Carrier:     carrier,
+		Error:       &LookupError{Code: ErrorCode(err), Message: err.Error()},
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store kept in a single JSON file, so that it persists across runs. Every operation reads the file
+// and every change rewrites it atomically, under an advisory lock on a neighboring .lock file, so that several
+// processes can share it. Watch only reports changes made through the same FileStore.
+type FileStore struct {
+	// LockTimeout is how long to wait for another process to release the store before failing with ErrLocked. Zero
+	// fails at once, and a negative timeout waits indefinitely.
+	LockTimeout time.Duration
+
+	path     string
+	mu       sync.Mutex
+	watchers map[chan Change]struct{}
+}
+
+var ErrLocked = errors.New("store is locked by another process")
+
+const (
+	LOCK_TIMEOUT = 10 * time.Second // the default FileStore.LockTimeout
+	LOCK_RETRY   = 50 * time.Millisecond
+)
+
+// storeFile is the contents of a FileStore's file.
+type storeFile struct {
+	Shipments []storedShipment `json:"shipments"`
+	Archived  []storedShipment `json:"archived,omitempty"` // moved out of Shipments by Archive
+	Removed   []storedShipment `json:"removed,omitempty"`  // moved out of Shipments or Archived by Remove
+}
+
+type storedShipment struct {
+	Shipment
+	Events []Update `json:"events,omitempty"` // most recent first
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{LockTimeout: LOCK_TIMEOUT, path: path, watchers: make(map[chan Change]struct{})}
+}
+
+// StorePath returns path if it is set, or else $PARCEL_STORE, or else DefaultStorePath.
+func StorePath(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv("PARCEL_STORE")
+	}
+	if path == "" {
+		return DefaultStorePath()
+	}
+	return path, nil
+}
+
+// DefaultStorePath returns the path of the store in the user's data directory: $XDG_DATA_HOME/parcel/store.json, or
+// the platform's equivalent.
+func DefaultStorePath() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		switch runtime.GOOS {
+		case "windows":
+			dir = os.Getenv("LocalAppData")
+		case "darwin":
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, "Library", "Application Support")
+		default:
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".local", "share")
+		}
+	}
+	if dir == "" {
+		return "", errors.New("no data directory")
+	}
+	return filepath.Join(dir, "parcel", "store.json"), nil
+}
+
+// view calls fn with the contents of the store.
+func (s *FileStore) view(fn func(data *storeFile) error) error {
+	return s.locked(false, func() error {
+		data, err := s.read()
+		if err != nil {
+			return err
+		}
+		return fn(data)
+	})
+}
+
+// update calls fn with the contents of the store, and saves them afterwards unless fn fails.
+func (s *FileStore) update(fn func(data *storeFile) error) error {
+	return s.locked(true, func() error {
+		data, err := s.read()
+		if err != nil {
+			return err
+		}
+		if err = fn(data); errors.Is(err, errUnchanged) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(data, "", "\t")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(s.path, b)
+	})
+}
+
+func (s *FileStore) locked(exclusive bool, fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err = lockFile(lock, exclusive, s.LockTimeout); err != nil {
+		if errors.Is(err, ErrLocked) {
+			return fmt.Errorf("%w: %s", err, s.path)
+		}
+		return err
+	}
+	defer unlockFile(lock)
+	return fn()
+}
+
+func (s *FileStore) read() (*storeFile, error) {
+	data := new(storeFile)
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, json.Unmarshal(b, data)
+}
+
+func (data *storeFile) find(key Key) *storedShipment {
+	return findStored(data.Shipments, key)
+}
+
+func findStored(list []storedShipment, key Key) *storedShipment {
+	for i := range list {
+		if list[i].Key == key {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// takeStored removes the shipment under key from list and returns it, if it is there.
+func takeStored(list *[]storedShipment, key Key) (storedShipment, bool) {
+	i := slices.IndexFunc(*list, func(stored storedShipment) bool { return stored.Key == key })
+	if i < 0 {
+		return *new(storedShipment), false
+	}
+	stored := (*list)[i]
+	*list = slices.Delete(*list, i, i+1)
+	return stored, true
+}
+
+func (s *FileStore) Get(ctx context.Context, key Key) (Shipment, error) {
+	var sh Shipment
+	err := s.view(func(data *storeFile) error {
+		stored := data.find(key)
+		if stored == nil {
+			return ErrNotFound
+		}
+		sh = stored.Shipment
+		return nil
+	})
+	return sh, err
+}
+
+func (s *FileStore) Put(ctx context.Context, sh Shipment) error {
+	err := s.update(func(data *storeFile) error {
+		if stored := data.find(sh.Key); stored != nil {
+			stored.Shipment = sh
+		} else {
+			data.Shipments = append(data.Shipments, storedShipment{Shipment: sh})
+		}
+		return nil
+	})
+	if err == nil {
+		s.notify(Change{Key: sh.Key})
+	}
+	return err
+}
+
+func (s *FileStore) ListShipments(ctx context.Context) ([]Shipment, error) {
+	var list []Shipment
+	err := s.view(func(data *storeFile) error {
+		list = make([]Shipment, 0, len(data.Shipments))
+		for _, stored := range data.Shipments {
+			list = append(list, stored.Shipment)
+		}
+		return nil
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, err
+}
+
+func (s *FileStore) Update(ctx context.Context, key Key, fn func(sh *Shipment, exists bool) ([]Update, error)) ([]Update, error) {
+	var added []Update
+	err := s.update(func(data *storeFile) error {
+		stored := data.find(key)
+		exists := stored != nil
+		if !exists {
+			// not saved unless fn succeeds
+			data.Shipments = append(data.Shipments, storedShipment{Shipment: Shipment{Key: key}})
+			stored = &data.Shipments[len(data.Shipments)-1]
+		}
+		events, err := fn(&stored.Shipment, exists)
+		if err != nil {
+			return err
+		}
+		stored.Key = key
+		stored.Events, added = mergeEvents(stored.Events, events)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(Change{Key: key, Events: added})
+	return added, nil
+}
+
+func (s *FileStore) AppendEvents(ctx context.Context, key Key, events []Update) ([]Update, error) {
+	var added []Update
+	err := s.update(func(data *storeFile) error {
+		stored := data.find(key)
+		if stored == nil {
+			return ErrNotFound
+		}
+		stored.Events, added = mergeEvents(stored.Events, events)
+		return nil
+	})
+	if err == nil && len(added) > 0 {
+		s.notify(Change{Key: key, Events: added})
+	}
+	return added, err
+}
+
+func (s *FileStore) Events(ctx context.Context, key Key) ([]Update, error) {
+	var events []Update
+	err := s.view(func(data *storeFile) error {
+		stored := data.find(key)
+		if stored == nil {
+			stored = findStored(data.Archived, key)
+		}
+		if stored == nil {
+			return ErrNotFound
+		}
+		events = stored.Events
+		return nil
+	})
+	return events, err
+}
+
+func (s *FileStore) Archive(ctx context.Context, t time.Time, purge bool) ([]Key, error) {
+	var keys []Key
+	err := s.update(func(data *storeFile) error {
+		kept := data.Shipments[:0]
+		for _, stored := range data.Shipments {
+			at, ok := deliveredAt(stored.Shipment)
+			if !ok || !at.Before(t) {
+				kept = append(kept, stored)
+				continue
+			}
+			keys = append(keys, stored.Key)
+			if purge {
+				continue
+			}
+			// a shipment tracked again after it was archived replaces its old entry
+			if old := findStored(data.Archived, stored.Key); old != nil {
+				*old = stored
+			} else {
+				data.Archived = append(data.Archived, stored)
+			}
+		}
+		data.Shipments = kept
+		if len(keys) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		s.notify(Change{Key: key})
+	}
+	return keys, nil
+}
+
+func (s *FileStore) ArchivedShipments(ctx context.Context) ([]Shipment, error) {
+	var list []Shipment
+	err := s.view(func(data *storeFile) error {
+		list = make([]Shipment, 0, len(data.Archived))
+		for _, stored := range data.Archived {
+			list = append(list, stored.Shipment)
+		}
+		return nil
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, err
+}
+
+func (s *FileStore) Remove(ctx context.Context, t time.Time, keys ...Key) ([]Key, error) {
+	var removed []Key
+	err := s.update(func(data *storeFile) error {
+		for _, key := range keys {
+			stored, ok := takeStored(&data.Shipments, key)
+			if !ok {
+				if stored, ok = takeStored(&data.Archived, key); !ok {
+					continue
+				}
+			}
+			stored.Removed = t
+			// a shipment removed again replaces its old entry
+			if old := findStored(data.Removed, key); old != nil {
+				*old = stored
+			} else {
+				data.Removed = append(data.Removed, stored)
+			}
+			removed = append(removed, key)
+		}
+		if len(removed) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range removed {
+		s.notify(Change{Key: key})
+	}
+	return removed, nil
+}
+
+func (s *FileStore) Restore(ctx context.Context, keys ...Key) ([]Key, error) {
+	var restored []Key
+	err := s.update(func(data *storeFile) error {
+		for _, key := range keys {
+			stored, ok := takeStored(&data.Removed, key)
+			if !ok {
+				continue
+			}
+			if cur := data.find(key); cur != nil {
+				cur.Events, _ = mergeEvents(cur.Events, stored.Events)
+			} else {
+				stored.Removed = *new(time.Time)
+				data.Shipments = append(data.Shipments, stored)
+			}
+			restored = append(restored, key)
+		}
+		if len(restored) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range restored {
+		s.notify(Change{Key: key})
+	}
+	return restored, nil
+}
+
+func (s *FileStore) RemovedShipments(ctx context.Context) ([]Shipment, error) {
+	var list []Shipment
+	err := s.view(func(data *storeFile) error {
+		list = make([]Shipment, 0, len(data.Removed))
+		for _, stored := range data.Removed {
+			list = append(list, stored.Shipment)
+		}
+		return nil
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, err
+}
+
+func (s *FileStore) PurgeRemoved(ctx context.Context, t time.Time) ([]Key, error) {
+	var keys []Key
+	err := s.update(func(data *storeFile) error {
+		data.Removed = slices.DeleteFunc(data.Removed, func(stored storedShipment) bool {
+			if stored.Removed.Before(t) {
+				keys = append(keys, stored.Key)
+				return true
+			}
+			return false
+		})
+		if len(keys) == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (s *FileStore) Watch(ctx context.Context) (<-chan Change, error) {
+	ch := make(chan Change, 16)
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.watchers, ch)
+		s.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// notify sends c to every watcher, dropping it for watchers that have fallen behind.
+func (s *FileStore) notify(c Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.watchers {
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	JSON        Format = "json"
+	NDJSON      Format = "ndjson"
+	CLOUDEVENTS Format = "cloudevents"
+	PROTO       Format = "proto"
+	TEXT        Format = "text"
+	TABLE       Format = "table"
+	MARKDOWN    Format = "md"
+	ICS         Format = "ics"
+	GEOJSON     Format = "geojson"
+	AFTERSHIP   Format = "aftership"
+	TEMPLATE    Format = "template"
+	QUERY       Format = "query" // selected by -q
+	GOB         Format = "gob"
+)
+
+const (
+	CE_SPEC_VERSION = "1.0"
+	CE_SOURCE       = "https://github.com/cdillond/parcel"
+	CE_TYPE         = "com.github.cdillond.parcel.result"
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured content mode, with the Result as its data.
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Subject         string `json:"subject"`
+	Time            string `json:"time"`
+	DataContentType string `json:"datacontenttype"`
+	Data            Result `json:"data"`
+}
+
+func ValidateFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case JSON:
+		return JSON, nil
+	case NDJSON:
+		return NDJSON, nil
+	case CLOUDEVENTS:
+		return CLOUDEVENTS, nil
+	case PROTO:
+		return PROTO, nil
+	case TEXT:
+		return TEXT, nil
+	case TABLE:
+		return TABLE, nil
+	case MARKDOWN, "markdown":
+		return MARKDOWN, nil
+	case ICS:
+		return ICS, nil
+	case GEOJSON:
+		return GEOJSON, nil
+	case AFTERSHIP:
+		return AFTERSHIP, nil
+	case TEMPLATE:
+		return TEMPLATE, nil
+	case GOB:
+		return GOB, nil
+	}
+	return *new(Format), ErrFormat
+}
+
+func NewCloudEvent(res Result) (CloudEvent, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return *new(CloudEvent), err
+	}
+	return CloudEvent{
+		SpecVersion:     CE_SPEC_VERSION,
+		ID:              hex.EncodeToString(id),
+		Source:          CE_SOURCE,
+		Type:            CE_TYPE,
+		Subject:         res.TrackingNum,
+		Time:            time.Now().Format(time.RFC3339),
+		DataContentType: "application/json",
+		Data:            res,
+	}, nil
+}
+
+// Marshal encodes res in the given format. Text formats are terminated by a newline.
+func Marshal(res Result, f Format, pretty bool) ([]byte, error) {
+	var v any = res
+	switch f {
+	case PROTO:
+		return MarshalProto(res), nil
+	case TEXT:
+		return MarshalText(res), nil
+	case TABLE:
+		return MarshalTable(res, 0, false), nil
+	case MARKDOWN:
+		return MarshalMarkdown(res), nil
+	case ICS:
+		return MarshalICS([]Result{res}), nil
+	case GEOJSON:
+		return MarshalGeoJSON([]Result{res}, pretty)
+	case AFTERSHIP:
+		return MarshalAfterShip([]Result{res}, true, pretty)
+	case TEMPLATE:
+		return MarshalTemplate(res)
+	case QUERY:
+		return MarshalQuery(res)
+	case NDJSON:
+		pretty = false
+	case CLOUDEVENTS:
+		ev, err := NewCloudEvent(res)
+		if err != nil {
+			return nil, err
+		}
+		v = ev
+	}
+	return marshalJSON(v, pretty)
+}
+
+// MarshalBatch encodes results as a single document: a JSON array for the json and cloudevents formats (the latter
+// being a CloudEvents JSON batch), a single calendar for ics, a single FeatureCollection for geojson, or a list of
+// trackings for aftership.
+func MarshalBatch(results []Result, f Format, pretty bool) ([]byte, error) {
+	var v any = results
+	switch f {
+	case ICS:
+		return MarshalICS(results), nil
+	case GEOJSON:
+		return MarshalGeoJSON(results, pretty)
+	case AFTERSHIP:
+		return MarshalAfterShip(results, false, pretty)
+	case CLOUDEVENTS:
+		evs := make([]CloudEvent, 0, len(results))
+		for _, res := range results {
+			ev, err := NewCloudEvent(res)
+			if err != nil {
+				return nil, err
+			}
+			evs = append(evs, ev)
+		}
+		v = evs
+	}
+	return marshalJSON(v, pretty)
+}
+
+func marshalJSON(v any, pretty bool) ([]byte, error) {
+	var b []byte
+	var err error
+	if pretty {
+		b, err = json.MarshalIndent(v, "", "\t")
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Streams reports whether results in format f can be written one after another as they complete.
+func (f Format) Streams() bool {
+	return f == NDJSON || f == PROTO || f == TEXT || f == TABLE || f == MARKDOWN || f == TEMPLATE || f == QUERY
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCheckDigits(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(string) int
+		body  string
+		want  int
+	}{
+		// UPS's published example, 1Z999AA10123456784
+		{"ups", upsCheckDigit, "999AA1012345678", 4},
+		// letters count as digits: A is 2, so the sum is 8×2 + 7×4 = 44
+		{"ups letters", upsCheckDigit, "AAAAAAAAAAAAAAA", 6},
+		{"ups zero", upsCheckDigit, "000000000000000", 0},
+		{"usps", mod10CheckDigit, "920559016491731275108", 9},
+		{"usps impb", mod10CheckDigit, "940010000000000000000", 6},
+		{"usps 30 digits", mod10CheckDigit, "420221539101026837331000039521", 9},
+		// FedEx's published example, 012345678983
+		{"fedex", fedexCheckDigit, "01234567898", 3},
+		// 1×1 + 3×3 = 10 mod 11, which gives a check digit of 0
+		{"fedex mod 11 is 10", fedexCheckDigit, "00000000031", 0},
+	}
+	for _, tt := range tests {
+		if got := tt.check(tt.body); got != tt.want {
+			t.Errorf("%s: check digit of %s is %d, want %d", tt.name, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCarrier(t *testing.T) {
+	tests := []struct {
+		num  string
+		want Carrier
+		ok   bool
+	}{
+		{"1Z999AA10123456784", UPS, true},
+		{"1Z999AA10123456785", "", false},
+		{"9205590164917312751089", USPS, true},
+		{"9205590164917312751088", "", false},
+		{"012345678983", FEDEX, true},
+		{"012345678984", "", false},
+		// DHL's mod 7 check digit: 331881002 % 7 == 5
+		{"3318810025", DHL, true},
+		{"3318810026", "", false},
+		{"1234567891", DHL, true},
+		{"12345", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := DetectCarrier(tt.num); got != tt.want || ok != tt.ok {
+			t.Errorf("DetectCarrier(%q) = %s, %t; want %s, %t", tt.num, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, carrier := range Carriers {
+		for i := 0; i < 100; i++ {
+			num := Generate(carrier)
+			if got, ok := DetectCarrier(num); !ok || got != carrier {
+				t.Fatalf("Generate(%s) = %s, detected as %s, %t", carrier, num, got, ok)
+			}
+			if _, err := SanitizeInput(num); err != nil {
+				t.Fatalf("Generate(%s) = %s: %v", carrier, num, err)
+			}
+		}
+	}
+	if n, _ := strconv.Atoi(Generate(DHL)); n == 0 {
+		t.Error("Generate(DHL) isn't numeric")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrGeocoder = errors.New("invalid geocoder")
+
+// GEOCODE_RPS is the most requests per second that NewGeocoder's HTTPGeocoders send, as Nominatim allows.
+const GEOCODE_RPS = 1
+
+// Coordinates are a latitude and longitude in decimal degrees.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// A Geocoder finds the coordinates of a location string as reported by a carrier. It returns false if the location
+// is unknown.
+type Geocoder interface {
+	Geocode(ctx context.Context, location string) (Coordinates, bool, error)
+}
+
+// Geo is the geocoder used to annotate updates with coordinates, if any.
+var Geo Geocoder
+
+// NewGeocoder returns the geocoder named by s: "offline" for the embedded gazetteer, or the URL of a
+// Nominatim-compatible search endpoint, in which {q} is replaced by the location.
+func NewGeocoder(s string) (Geocoder, error) {
+	if s == "offline" {
+		return Gazetteer, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(s, "{q}") {
+		return nil, fmt.Errorf("%w: %s", ErrGeocoder, s)
+	}
+	return &HTTPGeocoder{URL: s, Limiter: NewRateLimiter(GEOCODE_RPS)}, nil
+}
+
+//go:embed cities.csv
+var citiesCSV string
+
+type place struct {
+	key string
+	Coordinates
+}
+
+// gazetteer is a Geocoder that matches locations against a list of places.
+type gazetteer []place
+
+// Gazetteer knows the coordinates of the largest US cities and the cities of the major carrier hubs.
+var Gazetteer = loadGazetteer(citiesCSV)
+
+func loadGazetteer(s string) gazetteer {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic("cities.csv: " + err.Error())
+	}
+	g := make(gazetteer, 0, len(records))
+	for _, rec := range records {
+		lat, err1 := strconv.ParseFloat(rec[2], 64)
+		lon, err2 := strconv.ParseFloat(rec[3], 64)
+		if err1 != nil || err2 != nil {
+			panic("cities.csv: invalid coordinates for " + rec[0])
+		}
+		g = append(g, place{key: normalizeLocation(rec[0] + " " + rec[1]), Coordinates: Coordinates{lat, lon}})
+	}
+	return g
+}
+
+func (g gazetteer) Geocode(ctx context.Context, location string) (Coordinates, bool, error) {
+	c, ok := g.lookup(location)
+	return c, ok, nil
+}
+
+func (g gazetteer) lookup(location string) (Coordinates, bool) {
+	loc := normalizeLocation(location)
+	for _, p := range g {
+		if strings.Contains(loc, p.key) {
+			return p.Coordinates, true
+		}
+	}
+	return *new(Coordinates), false
+}
+
+// HTTPGeocoder is a Geocoder that queries a Nominatim-compatible search endpoint, which answers with a JSON array of
+// places with lat and lon strings. Answers are remembered for the life of the process.
+type HTTPGeocoder struct {
+	URL     string       // contains {q}
+	Limiter *RateLimiter // spaces the requests, if not nil
+
+	mu    sync.Mutex
+	known map[string]*Coordinates // nil for unknown locations
+}
+
+func (g *HTTPGeocoder) Geocode(ctx context.Context, location string) (Coordinates, bool, error) {
+	g.mu.Lock()
+	c, ok := g.known[location]
+	g.mu.Unlock()
+	if ok {
+		if c == nil {
+			return *new(Coordinates), false, nil
+		}
+		return *c, true, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(g.URL, "{q}", url.QueryEscape(location)), nil)
+	if err != nil {
+		return *new(Coordinates), false, err
+	}
+	if err = g.Limiter.Wait(ctx); err != nil {
+		return *new(Coordinates), false, err
+	}
+	req.Header.Set("User-Agent", PARCEL_USER_AGENT)
+	resp, err := ServiceClient.Do(req)
+	if err != nil {
+		return *new(Coordinates), false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return *new(Coordinates), false, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	var places []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return *new(Coordinates), false, err
+	}
+	if len(places) > 0 {
+		lat, err1 := strconv.ParseFloat(places[0].Lat, 64)
+		lon, err2 := strconv.ParseFloat(places[0].Lon, 64)
+		if err1 == nil && err2 == nil {
+			c = &Coordinates{lat, lon}
+		}
+	}
+
+	g.mu.Lock()
+	if g.known == nil {
+		g.known = make(map[string]*Coordinates)
+	}
+	g.known[location] = c
+	g.mu.Unlock()
+	if c == nil {
+		return *new(Coordinates), false, nil
+	}
+	return *c, true, nil
+}
+
+// GeocodeLocations adds coordinates to the updates of res: those of the facility, if it is known, or else those
+// found by Geo, which must be set. Locations that can't be geocoded are logged and left without coordinates.
+func GeocodeLocations(ctx context.Context, res *Result) {
+	for i, u := range res.Updates {
+		if u.Facility != nil {
+			res.Updates[i].Coordinates = &Coordinates{u.Facility.Latitude, u.Facility.Longitude}
+			continue
+		}
+		if u.Location == "" {
+			continue
+		}
+		c, ok, err := Geo.Geocode(ctx, u.Location)
+		if err != nil {
+			warn("geocoding failed", "location", u.Location, "err", err)
+			continue
+		}
+		if !ok {
+			debug("unknown location", "location", u.Location)
+			continue
+		}
+		res.Updates[i].Coordinates = &c
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+// GeoJSON (RFC 7946) types, as far as parcel needs them.
+type (
+	geoFeatureCollection struct {
+		Type     string       `json:"type"` // FeatureCollection
+		Features []geoFeature `json:"features"`
+	}
+	geoFeature struct {
+		Type       string         `json:"type"` // Feature
+		Geometry   geoGeometry    `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	}
+	geoGeometry struct {
+		Type        string `json:"type"` // Point or LineString
+		Coordinates any    `json:"coordinates"`
+	}
+)
+
+// MarshalGeoJSON renders the routes of results as a GeoJSON FeatureCollection: a Point feature for each update with a
+// known location, and a LineString feature through them, from the first scan to the latest, for each result with two
+// or more. Updates without coordinates are located by their facility, or else by the offline gazetteer.
+func MarshalGeoJSON(results []Result, pretty bool) ([]byte, error) {
+	fc := geoFeatureCollection{Type: "FeatureCollection", Features: []geoFeature{}}
+	for _, res := range results {
+		var route [][2]float64
+		for i := len(res.Updates) - 1; i >= 0; i-- {
+			u := res.Updates[i]
+			c, ok := locate(u)
+			if !ok {
+				continue
+			}
+			// GeoJSON positions are longitude first
+			pos := [2]float64{c.Longitude, c.Latitude}
+			if len(route) == 0 || route[len(route)-1] != pos {
+				route = append(route, pos)
+			}
+			fc.Features = append(fc.Features, geoFeature{
+				Type:     "Feature",
+				Geometry: geoGeometry{Type: "Point", Coordinates: pos},
+				Properties: map[string]any{
+					"trackingNum": res.TrackingNum,
+					"carrier":     res.Carrier,
+					"dateTime":    u.DateTime,
+					"location":    u.Location,
+					"status":      u.Status,
+				},
+			})
+		}
+		if len(route) < 2 {
+			continue
+		}
+		fc.Features = append(fc.Features, geoFeature{
+			Type:     "Feature",
+			Geometry: geoGeometry{Type: "LineString", Coordinates: route},
+			Properties: map[string]any{
+				"trackingNum": res.TrackingNum,
+				"carrier":     res.Carrier,
+				"state":       res.State,
+				"delivered":   res.Delivered,
+			},
+		})
+	}
+	return marshalJSON(fc, pretty)
+}
+
+// locate returns the coordinates of u, if they are known.
+func locate(u Update) (Coordinates, bool) {
+	switch {
+	case u.Coordinates != nil:
+		return *u.Coordinates, true
+	case u.Facility != nil:
+		return Coordinates{u.Facility.Latitude, u.Facility.Longitude}, true
+	}
+	return Gazetteer.lookup(u.Location)
+}
@@ -2,18 +2,57 @@ package main
 
 import (
 	"encoding/gob"
+	"errors"
+	"flag"
+	"io"
+	"os"
 )
 
-func EncodeGob(o string, res Result) error {
-	f, err := OutFile(o)
+// DecodeGob reads a stream of gob-encoded Results, as written by -gob, until EOF.
+func DecodeGob(r io.Reader) ([]Result, error) {
+	dec := gob.NewDecoder(r)
+	var results []Result
+	for {
+		var res Result
+		err := dec.Decode(&res)
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+}
+
+// runDecode implements the decode command, which converts gob output to another format.
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	format := fs.String("format", "json", "output format")
+	pretty := fs.Bool("pretty", false, "print the output json with indented fields")
+	fs.Parse(args)
+
+	f, err := ValidateFormat(*format)
 	if err != nil {
 		return err
 	}
-	enc := gob.NewEncoder(f)
-	err = enc.Encode(res)
+	in := os.Stdin
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		if in, err = os.Open(fs.Arg(0)); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+	results, err := DecodeGob(in)
 	if err != nil {
-		f.Close()
 		return err
 	}
-	return f.Close()
+
+	rw := NewResultWriter(os.Stdout, f, *pretty, len(results) != 1)
+	for _, res := range results {
+		if err = rw.Write(res); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// HA_PREFIX is Home Assistant's default MQTT discovery prefix.
+const HA_PREFIX = "homeassistant"
+
+// haSensor is the discovery config of a Home Assistant MQTT sensor.
+type haSensor struct {
+	Name                   string   `json:"name"`
+	UniqueID               string   `json:"unique_id"`
+	ObjectID               string   `json:"object_id"`
+	StateTopic             string   `json:"state_topic"`
+	ValueTemplate          string   `json:"value_template"`
+	JSONAttributesTopic    string   `json:"json_attributes_topic"`
+	JSONAttributesTemplate string   `json:"json_attributes_template"`
+	Icon                   string   `json:"icon"`
+	Device                 haDevice `json:"device"`
+}
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// the attributes of a shipment's sensor: its estimated delivery date and latest scan; fields that may be omitted from
+// the result are read with get
+const haAttributes = `{{ {` +
+	`"carrier": value_json.carrier, ` +
+	`"tracking_number": value_json.trackingNum, ` +
+	`"delivered": value_json.delivered, ` +
+	`"eta": value_json.get('deliveryDateTime'), ` +
+	`"last_location": value_json.updates[0].location if value_json.get('updates') else none, ` +
+	`"last_status": value_json.updates[0].status if value_json.get('updates') else none, ` +
+	`"last_update": value_json.updates[0].dateTime if value_json.get('updates') else none` +
+	`} | tojson }}`
+
+// haDiscovery returns the topic and payload that announce the shipment of res, whose results are published to
+// stateTopic, to Home Assistant as a sensor whose state is the shipment's state.
+func haDiscovery(prefix string, res Result, stateTopic string) (string, []byte, error) {
+	id := "parcel_" + haObjectID(string(res.Carrier)+"_"+res.TrackingNum)
+	b, err := json.Marshal(haSensor{
+		Name:                   Title(res),
+		UniqueID:               id,
+		ObjectID:               id,
+		StateTopic:             stateTopic,
+		ValueTemplate:          "{{ value_json.state }}",
+		JSONAttributesTopic:    stateTopic,
+		JSONAttributesTemplate: haAttributes,
+		Icon:                   "mdi:package-variant-closed",
+		Device:                 haDevice{Identifiers: []string{"parcel"}, Name: "parcel", Manufacturer: "parcel"},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return prefix + "/sensor/" + id + "/config", b, nil
+}
+
+// haObjectID lower-cases s and replaces anything other than letters, digits, and underscores, which Home Assistant
+// doesn't allow in object IDs, with underscores.
+func haObjectID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, s)
+}
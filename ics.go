@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const ICS_PRODID = "-//cdillond//parcel//EN"
+
+// MarshalICS renders results as an iCalendar document with an all-day VEVENT on the (estimated) delivery date of each
+// result. Results without a parsable delivery date are left out.
+func MarshalICS(results []Result) []byte {
+	b := new(strings.Builder)
+	icsLine(b, "BEGIN:VCALENDAR")
+	icsLine(b, "VERSION:2.0")
+	icsLine(b, "PRODID:"+ICS_PRODID)
+	icsLine(b, "CALSCALE:GREGORIAN")
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, res := range results {
+		dt, err := time.Parse(time.RFC3339, res.DeliveryDateTime)
+		if err != nil {
+			continue
+		}
+		summary := "Expected delivery: " + Title(res)
+		if res.Delivered {
+			summary = "Delivered: " + Title(res)
+		}
+		icsLine(b, "BEGIN:VEVENT")
+		icsLine(b, "UID:"+res.TrackingNum+"-"+strings.ToLower(string(res.Carrier))+"@parcel")
+		icsLine(b, "DTSTAMP:"+stamp)
+		icsLine(b, "DTSTART;VALUE=DATE:"+dt.Format("20060102"))
+		icsLine(b, "DTEND;VALUE=DATE:"+dt.AddDate(0, 0, 1).Format("20060102"))
+		icsLine(b, "SUMMARY:"+icsEscape(summary))
+		if len(res.Updates) > 0 {
+			icsLine(b, "DESCRIPTION:"+icsEscape(textUpdate(res.Updates[0])))
+		}
+		icsLine(b, "TRANSP:TRANSPARENT")
+		icsLine(b, "END:VEVENT")
+	}
+	icsLine(b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+var icsReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icsEscape(s string) string {
+	return icsReplacer.Replace(s)
+}
+
+// icsLine writes a content line, folding it so that no line exceeds 75 octets (RFC 5545, section 3.1).
+func icsLine(b *strings.Builder, line string) {
+	const limit = 75
+	for n := limit; len(line) > n; n = limit - 1 {
+		// don't split a multi-byte character
+		i := n
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+	}
+	b.WriteString(line + "\r\n")
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrImportSource = errors.New("unknown import source")
+	ErrNoneFound    = errors.New("no tracking numbers found")
+)
+
+// import sources, selected by the argument following import or by the scheme of a URL given in its place; a URL
+// is passed to the importer as its first argument
+var importers = map[string]func(args []string) ([]Job, error){
+	"photo":   importPhoto,
+	"pdf":     importPDF,
+	"email":   importEmail,
+	"amazon":  importAmazon,
+	"shopify": importShopify,
+	"imap":    importIMAP,
+	"imaps":   importIMAP,
+}
+
+// runImport implements the import command, which extracts tracking numbers and carriers from other sources and
+// prints them one per line, in the form read by -f, or as JSON with any merchants and order IDs found. Unless -add is
+// false, they are also added to the store, to be polled by -watch. The source is either one of importers or a URL
+// whose scheme is in importers.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	add := fs.Bool("add", true, "add the tracking numbers found to the store, to be polled by -watch; -add=false only prints them")
+	path := fs.String("store", "", "`path` of the store that the shipments are added to (default $PARCEL_STORE, or store.json in the user data directory)")
+	format := fs.String("format", "text", "output format: text or json")
+	var tags tagsFlag
+	fs.Var(&tags, "tag", "attach `tag` to the shipments added to the store; may be repeated")
+	fs.Parse(args)
+	args = fs.Args()
+	if len(args) == 0 {
+		return ErrImportSource
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("%w: %s", ErrFormat, *format)
+	}
+	name := args[0]
+	if scheme, _, ok := strings.Cut(name, "://"); ok {
+		name = scheme
+	} else {
+		args = args[1:]
+	}
+	importer, ok := importers[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrImportSource, name)
+	}
+	jobs, err := importer(args)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return ErrNoneFound
+	}
+	if *add {
+		p, err := StorePath(*path)
+		if err != nil {
+			return err
+		}
+		if err = addJobs(context.Background(), NewFileStore(p), jobs, tags, time.Now()); err != nil {
+			return err
+		}
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(jobs)
+	}
+	for _, job := range jobs {
+		fmt.Fprintln(os.Stdout, job.Num, job.Carrier)
+	}
+	return nil
+}
+
+// addJobs adds the jobs that aren't already in store to it, and adds tags to all of them.
+func addJobs(ctx context.Context, store Store, jobs []Job, tags []string, now time.Time) error {
+	for _, job := range jobs {
+		key := Key{Carrier: job.Carrier, TrackingNum: job.Num}
+		_, err := store.Update(ctx, key, func(sh *Shipment, exists bool) ([]Update, error) {
+			switch {
+			case !exists:
+				*sh = Shipment{Key: key, Added: now, Merchant: job.Merchant, OrderID: job.OrderID, Label: job.Label, Tags: tags}
+			case hasTags(sh.Tags, tags):
+				return nil, errUnchanged
+			default:
+				sh.Tags = mergeTags(sh.Tags, tags)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importPhoto reads tracking numbers from photos of shipping labels, using an OCR engine for the printed text and a
+// barcode reader for the barcodes. Both are external commands, in which {} is replaced by the image path.
+func importPhoto(args []string) ([]Job, error) {
+	fs := flag.NewFlagSet("import photo", flag.ExitOnError)
+	ocr := fs.String("ocr-cmd", "tesseract {} stdout", "OCR command that prints the text of an image; empty to disable")
+	barcode := fs.String("barcode-cmd", "zbarimg --quiet --raw {}", "command that prints the barcodes in an image; empty to disable")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return nil, errors.New("no image given")
+	}
+
+	var jobs []Job
+	for _, path := range fs.Args() {
+		jobs = append(jobs, extractFromImage(path, *ocr, *barcode)...)
+	}
+	return dedupeJobs(jobs), nil
+}
+
+func extractFromImage(path, ocr, barcode string) []Job {
+	var text strings.Builder
+	for _, cmd := range []string{barcode, ocr} {
+		out, err := runExtractor(cmd, strings.NewReplacer("{}", path))
+		if err != nil {
+			warn("import failed", "path", path, "err", err)
+			continue
+		}
+		text.WriteString(out + "\n")
+	}
+	return ExtractTrackingNumbers(text.String())
+}
+
+// importPDF reads tracking numbers from shipping label and receipt PDFs. The text layer is tried first; if it has no
+// tracking numbers (e.g. because the PDF is a scan), the pages are rendered to images and read as photos.
+func importPDF(args []string) ([]Job, error) {
+	fs := flag.NewFlagSet("import pdf", flag.ExitOnError)
+	text := fs.String("text-cmd", "pdftotext -layout {} -", "command that prints the text layer of a PDF; empty to disable")
+	render := fs.String("render-cmd", "pdftoppm -r 300 -png {} {dir}/page", "command that renders the pages of a PDF as images in {dir}; empty to disable OCR")
+	ocr := fs.String("ocr-cmd", "tesseract {} stdout", "OCR command that prints the text of an image; empty to disable")
+	barcode := fs.String("barcode-cmd", "zbarimg --quiet --raw {}", "command that prints the barcodes in an image; empty to disable")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return nil, errors.New("no PDF given")
+	}
+
+	var jobs []Job
+	for _, path := range fs.Args() {
+		out, err := runExtractor(*text, strings.NewReplacer("{}", path))
+		if err != nil {
+			warn("import failed", "path", path, "err", err)
+		}
+		found := ExtractTrackingNumbers(out)
+		if len(found) == 0 && *render != "" {
+			found, err = extractFromPages(path, *render, *ocr, *barcode)
+			if err != nil {
+				warn("import failed", "path", path, "err", err)
+			}
+		}
+		jobs = append(jobs, found...)
+	}
+	return dedupeJobs(jobs), nil
+}
+
+func extractFromPages(path, render, ocr, barcode string) ([]Job, error) {
+	dir, err := os.MkdirTemp("", "parcel-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if _, err = runExtractor(render, strings.NewReplacer("{}", path, "{dir}", dir)); err != nil {
+		return nil, err
+	}
+	pages, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, page := range pages {
+		jobs = append(jobs, extractFromImage(filepath.Join(dir, page.Name()), ocr, barcode)...)
+	}
+	return jobs, nil
+}
+
+// runExtractor runs the command line cmd, with its placeholders replaced by r, and returns its output.
+func runExtractor(cmd string, r *strings.Replacer) (string, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	for i := range fields {
+		fields[i] = r.Replace(fields[i])
+	}
+	out, err := exec.Command(fields[0], fields[1:]...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %s", fields[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func dedupeJobs(jobs []Job) []Job {
+	seen := make(map[string]bool)
+	out := jobs[:0]
+	for _, job := range jobs {
+		if !seen[job.Num] {
+			seen[job.Num] = true
+			out = append(out, job)
+		}
+	}
+	return out
+}
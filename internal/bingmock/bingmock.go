@@ -0,0 +1,171 @@
+// Package bingmock serves synthetic package tracking pages in the markup of Bing's package tracking page, so that
+// parcel can be exercised end to end without network access.
+//
+// The state of a shipment is chosen by the tracking number: a number containing the name of a State (ignoring case)
+// is given that state, and any other number is assigned one deterministically.
+package bingmock
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+const PATH = "/packagetrackingv2"
+
+type State string
+
+const (
+	PRE_TRANSIT      State = "pretransit"
+	IN_TRANSIT       State = "intransit"
+	OUT_FOR_DELIVERY State = "outfordelivery"
+	DELIVERED        State = "delivered"
+	EXCEPTION        State = "exception"
+	NOT_FOUND        State = "notfound"
+	RATE_LIMITED     State = "ratelimited"
+)
+
+// States lists the states that numbers without a state name are assigned from. RATE_LIMITED is left out, since it
+// doesn't produce a page.
+var States = []State{PRE_TRANSIT, IN_TRANSIT, OUT_FOR_DELIVERY, DELIVERED, EXCEPTION, NOT_FOUND}
+
+// StateOf returns the state of the shipment with tracking number num.
+func StateOf(num string) State {
+	lower := strings.ToLower(num)
+	for _, s := range append(States, RATE_LIMITED) {
+		if strings.Contains(lower, string(s)) {
+			return s
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(num))
+	return States[h.Sum32()%uint32(len(States))]
+}
+
+type update struct {
+	Date, Time, Location, Status string
+}
+
+type page struct {
+	Banner  string
+	Updates []update
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><title>Package tracking</title></head>
+<body><div id="b_content"><div class="b_ans">
+{{if .Banner}}<div class="b_focusTextSmall">{{.Banner}}</div>{{end}}
+{{if .Updates}}<table class="b_tracking">{{range .Updates}}
+<tr><td>{{.Date}}</td><td>{{.Time}}</td><td>{{.Location}}</td><td>{{.Status}}</td></tr>{{end}}
+</table>{{end}}
+</div></div></body></html>
+`))
+
+// Handler serves tracking pages at PATH, for the packNum query parameter. Pages carry an ETag, and conditional
+// requests for an unchanged page are answered with 304 Not Modified.
+type Handler struct {
+	// Now returns the current time, which the synthetic updates are dated relative to. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PATH {
+		http.NotFound(w, r)
+		return
+	}
+	num := r.URL.Query().Get("packNum")
+	if num == "" {
+		http.Error(w, "missing packNum", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if h.Now != nil {
+		now = h.Now()
+	}
+
+	state := StateOf(num)
+	if state == RATE_LIMITED {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	b := new(bytes.Buffer)
+	pageTemplate.Execute(b, pageFor(state, now))
+	sum := fnv.New64a()
+	sum.Write(b.Bytes())
+	etag := fmt.Sprintf(`"%x"`, sum.Sum64())
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// pageFor builds the page for a shipment in state s, with its most recent update a little before now.
+func pageFor(s State, now time.Time) page {
+	now = now.Truncate(time.Minute)
+	at := func(hours int) time.Time {
+		return now.Add(-time.Duration(hours) * time.Hour)
+	}
+	history := []struct {
+		t        time.Time
+		location string
+		status   string
+	}{
+		{at(50), "Memphis, TN, United States", "Shipping label created, awaiting item"},
+		{at(46), "Memphis, TN, United States", "Accepted at origin facility"},
+		{at(30), "Louisville, KY, United States", "Arrived at regional facility"},
+		{at(20), "Jersey City, NJ, United States", "Departed regional facility"},
+		{at(6), "Brooklyn, NY, United States", "Arrived at post office"},
+		{at(3), "Brooklyn, NY, United States", "Out for delivery"},
+		{at(1), "Brooklyn, NY, United States", "Delivered, in/at mailbox"},
+	}
+
+	var n int
+	var p page
+	eta := now.AddDate(0, 0, 2)
+	switch s {
+	case NOT_FOUND:
+		return p
+	case PRE_TRANSIT:
+		n = 1
+		p.Banner = "Expected delivery: " + eta.Format("Monday, January 2, 2006")
+	case IN_TRANSIT:
+		n = 4
+		p.Banner = "Expected delivery: " + eta.Format("Monday, January 2, 2006")
+	case OUT_FOR_DELIVERY:
+		n = 6
+		p.Banner = "Expected delivery: " + now.Format("Monday, January 2, 2006")
+	case DELIVERED:
+		n = 7
+		p.Banner = "Delivered: " + history[6].t.Format("Mon, Jan 02, 3:04 PM")
+	case EXCEPTION:
+		n = 4
+		history[3].status = "Delivery exception, delayed due to weather"
+		p.Banner = "Expected delivery: " + eta.Format("Monday, January 2, 2006")
+	}
+
+	// most recent first
+	for i := n - 1; i >= 0; i-- {
+		u := history[i]
+		p.Updates = append(p.Updates, update{
+			Date:     u.t.Format("Jan 2"),
+			Time:     u.t.Format("3:04 PM"),
+			Location: u.location,
+			Status:   u.status,
+		})
+	}
+	return p
+}
+
+// NewServer starts a server for Handler on a local port. The caller should call Close when finished with it.
+func NewServer() *httptest.Server {
+	return httptest.NewServer(Handler{})
+}
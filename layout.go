@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Layout is a parser for one variant of the tracking page markup. Bing serves different markup to different users,
+// so each variant gets its own parser; they are tried in order until one finds a delivery status or updates.
+type Layout struct {
+	Name  string
+	Parse func(r io.Reader) (Result, error)
+}
+
+var Layouts = []Layout{
+	{Name: "table", Parse: ParseTable},
+	{Name: "text", Parse: ParseText},
+}
+
+// ParseText is a fallback that ignores the markup entirely and looks for a delivery status line (e.g.
+// "Delivered: Tue, Sep 19, 2:51 PM") in any text node. It cannot recover individual updates.
+func ParseText(r io.Reader) (Result, error) {
+	var res Result
+	tokenizer := html.NewTokenizer(r)
+	for tType := tokenizer.Next(); tType != html.ErrorToken; tType = tokenizer.Next() {
+		if tType != html.TextToken {
+			continue
+		}
+		label, date, ok := strings.Cut(strings.TrimSpace(string(tokenizer.Text())), ": ")
+		if !ok {
+			continue
+		}
+		switch {
+		case label == "Delivered":
+			dt := ParseDeliveryDate(date)
+			if dt == date {
+				continue
+			}
+			res.Delivered = true
+			res.DeliveryDateTime = dt
+			return res, nil
+		case strings.Contains(strings.ToLower(label), "delivery"):
+			dt := ParseEstimatedDelivery(date)
+			if dt == date {
+				continue
+			}
+			res.DeliveryDateTime = dt
+			return res, nil
+		}
+	}
+	if err := tokenizer.Err(); err != io.EOF {
+		return *new(Result), err
+	}
+	return res, nil
+}
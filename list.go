@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrState = errors.New("invalid state")
+	ErrAge   = errors.New("invalid age")
+)
+
+// runList implements the list command, which prints the shipments in the store, optionally filtered by the flags of
+// shipmentFilter.
+func runList(args []string) error {
+	return printReport("list", func(ctx context.Context, store Store) (report, error) {
+		shipments, err := listShipments(ctx, store)
+		return &ShipmentList{Shipments: shipments}, err
+	}, args, false)
+}
+
+// ShipmentList is the list of shipments in the store, in the order that they were added.
+type ShipmentList struct {
+	Shipments []Shipment `json:"shipments"`
+}
+
+func (l *ShipmentList) Rows() [][]string {
+	rows := [][]string{{"CARRIER", "TRACKING NUMBER", "LABEL", "STATE", "TAGS", "ADDED", "CHECKED", "NOTE"}}
+	for _, sh := range l.Shipments {
+		state, checked := string(sh.Result.State), "-"
+		if state == "" {
+			state = "-"
+		}
+		if !sh.Checked.IsZero() {
+			checked = sh.Checked.Local().Format(time.DateOnly)
+		}
+		rows = append(rows, []string{
+			string(sh.Carrier),
+			sh.TrackingNum,
+			sh.Label,
+			state,
+			strings.Join(sh.Tags, ", "),
+			sh.Added.Local().Format(time.DateOnly),
+			checked,
+			sh.Note,
+		})
+	}
+	return rows
+}
+
+func (l *ShipmentList) Summary() string {
+	if len(l.Shipments) == 1 {
+		return "1 shipment\n"
+	}
+	return fmt.Sprintf("%d shipments\n", len(l.Shipments))
+}
+
+// shipmentFilter selects shipments by the flags shared by list and the reports.
+type shipmentFilter struct {
+	state   string
+	carrier string
+	since   string
+	older   string
+	tags    tagsFlag
+	search  string
+
+	after  time.Time // parsed from since
+	before time.Time // parsed from older
+}
+
+func (f *shipmentFilter) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.state, "status", "", "only include shipments in `state`: not_found, pre_transit, in_transit, stalled, or delivered")
+	fs.StringVar(&f.carrier, "carrier", "", "only include shipments by `carrier`")
+	fs.StringVar(&f.since, "since", "", "only include shipments added on or after this `date` (YYYY-MM-DD)")
+	fs.StringVar(&f.older, "older-than", "", "only include shipments added longer than `age` ago, in days as in 60d or as a duration such as 12h")
+	fs.Var(&f.tags, "tag", "only include shipments with `tag`; may be repeated")
+	fs.StringVar(&f.search, "search", "", "only include shipments whose label, note, merchant, or order ID contains `text`")
+}
+
+func (f *shipmentFilter) active() bool {
+	return f.state != "" || f.carrier != "" || f.since != "" || f.older != "" || len(f.tags) > 0 || f.search != ""
+}
+
+// validate normalizes the flags and reports the first invalid one.
+func (f *shipmentFilter) validate() error {
+	f.state = strings.ToLower(f.state)
+	switch State(f.state) {
+	case "", NOT_FOUND, PRE_TRANSIT, IN_TRANSIT, STALLED, DELIVERED:
+	default:
+		return fmt.Errorf("%w: %s", ErrState, f.state)
+	}
+	if f.carrier != "" {
+		c, err := ValidateCarrier(f.carrier)
+		if err != nil {
+			return err
+		}
+		f.carrier = string(c)
+	}
+	if f.since != "" {
+		var err error
+		if f.after, err = time.ParseInLocation(time.DateOnly, f.since, time.Local); err != nil {
+			return ErrDate
+		}
+	}
+	if f.older != "" {
+		age, err := parseAge(f.older)
+		if err != nil {
+			return err
+		}
+		f.before = time.Now().Add(-age)
+	}
+	f.search = strings.ToLower(f.search)
+	return nil
+}
+
+func (f *shipmentFilter) match(sh Shipment) bool {
+	switch {
+	case f.state != "" && string(sh.Result.State) != f.state,
+		f.carrier != "" && string(sh.Carrier) != f.carrier,
+		sh.Added.Before(f.after),
+		!f.before.IsZero() && !sh.Added.Before(f.before),
+		!hasTags(sh.Tags, f.tags):
+		return false
+	case f.search == "":
+		return true
+	}
+	for _, s := range []string{sh.Label, sh.Note, sh.Merchant, sh.OrderID} {
+		if strings.Contains(strings.ToLower(s), f.search) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAge parses a number of days, such as 60d, or a duration as accepted by time.ParseDuration.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrAge, s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrAge, s)
+	}
+	return age, nil
+}
+
+// filteredStore limits the shipments listed by a Store to those matched by its filter.
+type filteredStore struct {
+	Store
+	filter *shipmentFilter
+}
+
+func (s filteredStore) ListShipments(ctx context.Context) ([]Shipment, error) {
+	list, err := s.Store.ListShipments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(list, func(sh Shipment) bool { return !s.filter.match(sh) }), nil
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly)
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile does nothing on this platform; concurrent processes are not protected from each other.
+func lockFile(f *os.File, exclusive bool, timeout time.Duration) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const LOG_BACKUP_TIME_FORMAT = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a log file that is rotated once it grows past MaxSize bytes. Rotated files are renamed with the
+// time of rotation, e.g. parcel-2006-01-02T15-04-05.000.log for parcel.log, and are removed once there are more than
+// Keep of them or they are older than MaxAge. A zero Keep or MaxAge means no limit.
+type RotatingFile struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
+	Keep    int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it and its directory if necessary.
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, keep int) (*RotatingFile, error) {
+	r := &RotatingFile{Path: path, MaxSize: maxSize, MaxAge: maxAge, Keep: keep}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, fi.Size()
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// rotate renames the current file and starts a new one. r.mu must be held.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(r.Path)
+	backup := strings.TrimSuffix(r.Path, ext) + "-" + time.Now().UTC().Format(LOG_BACKUP_TIME_FORMAT) + ext
+	if err := os.Rename(r.Path, backup); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+// prune removes the rotated files that are past the limits. Failures are ignored; they are retried on the next
+// rotation. Files that merely share the log file's name, such as parcel-old.log, aren't backups and are left alone.
+func (r *RotatingFile) prune() {
+	ext := filepath.Ext(r.Path)
+	prefix := strings.TrimSuffix(r.Path, ext) + "-"
+	matches, _ := filepath.Glob(prefix + "*" + ext)
+	var backups []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), ext)
+		if t, err := time.Parse(LOG_BACKUP_TIME_FORMAT, stamp); err == nil && t.Format(LOG_BACKUP_TIME_FORMAT) == stamp {
+			backups = append(backups, m)
+		}
+	}
+	// the time format sorts chronologically; most recent first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, b := range backups {
+		fi, err := os.Stat(b)
+		if err != nil {
+			continue
+		}
+		if (r.Keep > 0 && i >= r.Keep) || (r.MaxAge > 0 && time.Since(fi.ModTime()) > r.MaxAge) {
+			os.Remove(b)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRotatingFilePrune(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"parcel.log",
+		"parcel-2024-05-01T10-00-00.000.log",
+		"parcel-2024-05-02T10-00-00.000.log",
+		"parcel-2024-05-03T10-00-00.000.log",
+		// not written by RotatingFile
+		"parcel-old.log",
+		"parcel-debug.log",
+		"parcel-2024-05-01.log",
+		"parcel-2024-05-01T10-00-00.log",
+		"parcel-2024-05-01T10-00-00.000.txt",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &RotatingFile{Path: filepath.Join(dir, "parcel.log"), Keep: 1, MaxAge: time.Hour}
+	r.prune()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	want := []string{
+		"parcel-2024-05-01.log",
+		"parcel-2024-05-01T10-00-00.000.txt",
+		"parcel-2024-05-01T10-00-00.log",
+		"parcel-2024-05-03T10-00-00.000.log",
+		"parcel-debug.log",
+		"parcel-old.log",
+		"parcel.log",
+	}
+	if !slices.Equal(left, want) {
+		t.Errorf("left %v, want %v", left, want)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Priority is a syslog message priority.
+type Priority int
+
+const (
+	PRI_ERR     Priority = 3
+	PRI_WARNING Priority = 4
+	PRI_INFO    Priority = 6
+	PRI_DEBUG   Priority = 7
+)
+
+const (
+	LOG_STDERR   = "stderr"
+	LOG_SYSLOG   = "syslog"
+	LOG_JOURNALD = "journald"
+)
+
+const (
+	LOG_TEXT = "text"
+	LOG_JSON = "json"
+)
+
+var (
+	ErrLogOutput = errors.New("invalid log output")
+	ErrLogFormat = errors.New("invalid log format")
+)
+
+// Logger is the destination of all log messages. Until SetupLogging is called, it writes warnings and errors to
+// stderr as text.
+var Logger = slog.New(newOutputHandler(stderrOutput, LOG_TEXT, slog.LevelWarn, true))
+
+// SetupLogging directs log messages at or above level to stderr, the local syslog daemon, or the systemd journal,
+// formatted as logfmt-style text or as JSON.
+func SetupLogging(output, format string, level slog.Level) error {
+	format = strings.ToLower(format)
+	if err := validateLogFormat(format); err != nil {
+		return err
+	}
+	var out func(p Priority, msg string) error
+	switch strings.ToLower(output) {
+	case LOG_STDERR:
+		out = stderrOutput
+	case LOG_SYSLOG:
+		var err error
+		if out, err = syslogOutput(); err != nil {
+			return err
+		}
+	case LOG_JOURNALD:
+		// journald reads a <priority> prefix on each line written to a stream it is attached to
+		out = func(p Priority, msg string) error {
+			_, err := fmt.Fprintf(os.Stderr, "<%d>%s\n", p, msg)
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrLogOutput, output)
+	}
+	// syslog and journald timestamp messages themselves
+	Logger = slog.New(newOutputHandler(out, format, level, output == LOG_STDERR))
+	return nil
+}
+
+// SetupFileLogging directs log messages at or above level to w, one per line, formatted as for SetupLogging.
+func SetupFileLogging(w io.Writer, format string, level slog.Level) error {
+	format = strings.ToLower(format)
+	if err := validateLogFormat(format); err != nil {
+		return err
+	}
+	out := func(_ Priority, msg string) error {
+		_, err := io.WriteString(w, msg+"\n")
+		return err
+	}
+	Logger = slog.New(newOutputHandler(out, format, level, true))
+	return nil
+}
+
+func validateLogFormat(format string) error {
+	if format != LOG_TEXT && format != LOG_JSON {
+		return fmt.Errorf("%w: %s", ErrLogFormat, format)
+	}
+	return nil
+}
+
+// LogLevel returns the level selected by the -v and -vv flags.
+func LogLevel(v, vv bool) slog.Level {
+	switch {
+	case vv:
+		return slog.LevelDebug
+	case v:
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+func stderrOutput(_ Priority, msg string) error {
+	_, err := fmt.Fprintln(os.Stderr, msg)
+	return err
+}
+
+// outputHandler formats each record with a text or JSON handler, then passes the line to out along with the syslog
+// priority matching its level. If out fails, the line is written to stderr instead.
+type outputHandler struct {
+	h   slog.Handler
+	buf *bytes.Buffer
+	mu  *sync.Mutex
+	out func(p Priority, msg string) error
+}
+
+func newOutputHandler(out func(p Priority, msg string) error, format string, level slog.Level, timestamps bool) *outputHandler {
+	buf := new(bytes.Buffer)
+	opts := &slog.HandlerOptions{Level: level}
+	if !timestamps {
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+	}
+	var h slog.Handler = slog.NewTextHandler(buf, opts)
+	if format == LOG_JSON {
+		h = slog.NewJSONHandler(buf, opts)
+	}
+	return &outputHandler{h: h, buf: buf, mu: new(sync.Mutex), out: out}
+}
+
+func (h *outputHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *outputHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf.Reset()
+	if err := h.h.Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(h.buf.String(), "\n")
+	p := PRI_DEBUG
+	switch {
+	case r.Level >= slog.LevelError:
+		p = PRI_ERR
+	case r.Level >= slog.LevelWarn:
+		p = PRI_WARNING
+	case r.Level >= slog.LevelInfo:
+		p = PRI_INFO
+	}
+	if err := h.out(p, msg); err != nil {
+		return stderrOutput(p, msg)
+	}
+	return nil
+}
+
+func (h *outputHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &outputHandler{h: h.h.WithAttrs(attrs), buf: h.buf, mu: h.mu, out: h.out}
+}
+
+func (h *outputHandler) WithGroup(name string) slog.Handler {
+	return &outputHandler{h: h.h.WithGroup(name), buf: h.buf, mu: h.mu, out: h.out}
+}
+
+func debug(msg string, args ...any) {
+	Logger.Debug(msg, args...)
+}
+
+func info(msg string, args ...any) {
+	Logger.Info(msg, args...)
+}
+
+func warn(msg string, args ...any) {
+	Logger.Warn(msg, args...)
+}
+
+func logErr(msg string, args ...any) {
+	Logger.Error(msg, args...)
+}
+
+// fatal logs msg at error level and exits with EXIT_ERROR.
+func fatal(msg string, args ...any) {
+	fatalWith(EXIT_ERROR, msg, args...)
+}
+
+func fatalWith(code int, msg string, args ...any) {
+	logErr(msg, args...)
+	os.Exit(code)
+}
@@ -0,0 +1,40 @@
+package main
+
+import "strings"
+
+// MarshalMarkdown renders res as a Markdown section with a table of its updates.
+func MarshalMarkdown(res Result) []byte {
+	b := new(strings.Builder)
+	b.WriteString("### " + mdEscape(Title(res)) + "\n\n")
+	switch {
+	case res.Error != nil:
+		b.WriteString("**Error:** " + mdEscape(res.Error.Message) + "\n")
+		return []byte(b.String())
+	case res.Delivered:
+		b.WriteString("**Delivered:** " + mdEscape(textTime(res.DeliveryDateTime)) + "\n")
+	case res.DeliveryDateTime != "":
+		b.WriteString("**Expected delivery:** " + mdEscape(textDate(res.DeliveryDateTime)) + "\n")
+	default:
+		b.WriteString("**Status:** not yet delivered\n")
+	}
+	if len(res.Updates) == 0 {
+		b.WriteString("\n_No tracking updates found._\n")
+		return []byte(b.String())
+	}
+
+	b.WriteString("\n| Date | Location | Status |\n| --- | --- | --- |\n")
+	for _, u := range res.Updates {
+		b.WriteString("| " + mdEscape(textTime(u.DateTime)) + " | " + mdEscape(u.Location) + " | " + mdEscape(u.Status) + " |\n")
+	}
+	return []byte(b.String())
+}
+
+var mdReplacer = strings.NewReplacer(
+	"\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]", "<", "&lt;", ">", "&gt;",
+	"\n", " ",
+)
+
+// mdEscape escapes s so that it renders literally inside a Markdown table cell.
+func mdEscape(s string) string {
+	return mdReplacer.Replace(s)
+}
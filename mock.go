@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+
+	"github.com/cdillond/parcel/internal/bingmock"
+)
+
+// runMockUpstream implements the mock-upstream command, which serves synthetic tracking pages in place of Bing.
+func runMockUpstream(args []string) error {
+	fs := flag.NewFlagSet("mock-upstream", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	fs.Parse(args)
+
+	// the address is worth printing even without -v, which the main flags would set
+	if err := SetupLogging(LOG_STDERR, LOG_TEXT, slog.LevelInfo); err != nil {
+		return err
+	}
+	info("serving synthetic tracking pages", "url", "http://"+*addr+bingmock.PATH)
+	return http.ListenAndServe(*addr, bingmock.Handler{})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMQTTURL     = errors.New("invalid MQTT broker URL")
+	ErrMQTTRefused = errors.New("MQTT broker refused the connection")
+	ErrMQTTPacket  = errors.New("unexpected MQTT packet")
+)
+
+// MQTT control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttDisconnect = 14
+)
+
+// MQTT_TOPIC is the default topic template; {num} and {carrier} are replaced by those of the result.
+const MQTT_TOPIC = "parcel/{num}"
+
+// MQTTPublisher publishes results to an MQTT 3.1.1 broker as retained JSON messages with QoS 1, so that subscribers
+// that connect later still see the latest result for each shipment. It connects on first use and reconnects once
+// whenever publishing fails.
+type MQTTPublisher struct {
+	Broker *url.URL // tcp:// or mqtt:// for plain connections, ssl://, tls://, or mqtts:// for TLS; with an optional user:password
+	Topic  string   // a template as for MQTT_TOPIC
+	// Discovery, if set, is the Home Assistant discovery prefix under which each shipment is announced as a sensor
+	// before its first result is published.
+	Discovery string
+
+	announced sync.Map // topics announced to Home Assistant
+	mu        sync.Mutex
+	conn      net.Conn
+	r         *bufio.Reader
+	id        string
+	packet    uint16
+}
+
+// MQTT is the publisher selected by -mqtt, if any.
+var MQTT *MQTTPublisher
+
+func NewMQTTPublisher(broker, topic string) (*MQTTPublisher, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMQTTURL, broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrMQTTURL, broker)
+	}
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &MQTTPublisher{Broker: u, Topic: topic, id: "parcel-" + hex.EncodeToString(id)}, nil
+}
+
+// PublishResult publishes res to its topic, announcing its shipment to Home Assistant first if need be.
+func (p *MQTTPublisher) PublishResult(res Result) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	topic := strings.NewReplacer("{num}", res.TrackingNum, "{carrier}", string(res.Carrier)).Replace(p.Topic)
+	if p.Discovery != "" {
+		if _, ok := p.announced.Load(topic); !ok {
+			config, payload, err := haDiscovery(p.Discovery, res, topic)
+			if err != nil {
+				return err
+			}
+			if err = p.Publish(config, payload, true); err != nil {
+				return err
+			}
+			p.announced.Store(topic, true)
+		}
+	}
+	return p.Publish(topic, b, true)
+}
+
+// Publish sends payload to topic and waits for the broker to acknowledge it.
+func (p *MQTTPublisher) Publish(topic string, payload []byte, retain bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.publish(topic, payload, retain)
+	if err != nil && p.conn != nil {
+		// the broker may have dropped an idle connection
+		p.close()
+		err = p.publish(topic, payload, retain)
+	}
+	if err != nil {
+		p.close()
+	}
+	return err
+}
+
+// Close disconnects from the broker. It does nothing if p is nil.
+func (p *MQTTPublisher) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	p.conn.Write([]byte{mqttDisconnect << 4, 0})
+	return p.close()
+}
+
+func (p *MQTTPublisher) close() error {
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.r = nil, nil
+	return err
+}
+
+func (p *MQTTPublisher) publish(topic string, payload []byte, retain bool) error {
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+	p.packet++
+	if p.packet == 0 {
+		p.packet = 1 // 0 is not a valid packet identifier
+	}
+	body := appendMQTTString(nil, topic)
+	body = binary.BigEndian.AppendUint16(body, p.packet)
+	body = append(body, payload...)
+	flags := byte(1 << 1) // QoS 1
+	if retain {
+		flags |= 1
+	}
+	p.conn.SetDeadline(time.Now().Add(TIMEOUT))
+	if _, err := p.conn.Write(mqttPacket(mqttPublish<<4|flags, body)); err != nil {
+		return err
+	}
+	typ, resp, err := p.read()
+	if err != nil {
+		return err
+	}
+	if typ != mqttPuback || len(resp) < 2 || binary.BigEndian.Uint16(resp) != p.packet {
+		return fmt.Errorf("%w: type %d", ErrMQTTPacket, typ)
+	}
+	return nil
+}
+
+func (p *MQTTPublisher) connect() error {
+	addr := p.Broker.Host
+	tlsConn := p.Broker.Scheme == "ssl" || p.Broker.Scheme == "tls" || p.Broker.Scheme == "mqtts"
+	if p.Broker.Port() == "" {
+		if tlsConn {
+			addr = net.JoinHostPort(p.Broker.Hostname(), "8883")
+		} else {
+			addr = net.JoinHostPort(p.Broker.Hostname(), "1883")
+		}
+	}
+	dialer := &net.Dialer{Timeout: TIMEOUT}
+	var conn net.Conn
+	var err error
+	if tlsConn {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: p.Broker.Hostname(), MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	p.conn, p.r = conn, bufio.NewReader(conn)
+
+	// variable header: protocol name and level, connect flags, and keep alive (0 disables it)
+	body := appendMQTTString(nil, "MQTT")
+	flags := byte(1 << 1) // clean session
+	user := p.Broker.User
+	if user != nil {
+		flags |= 1 << 7
+		if _, ok := user.Password(); ok {
+			flags |= 1 << 6
+		}
+	}
+	body = append(body, 4, flags, 0, 0)
+	body = appendMQTTString(body, p.id)
+	if user != nil {
+		body = appendMQTTString(body, user.Username())
+		if pw, ok := user.Password(); ok {
+			body = appendMQTTString(body, pw)
+		}
+	}
+	conn.SetDeadline(time.Now().Add(TIMEOUT))
+	if _, err = conn.Write(mqttPacket(mqttConnect<<4, body)); err != nil {
+		p.close()
+		return err
+	}
+	typ, resp, err := p.read()
+	if err != nil {
+		p.close()
+		return err
+	}
+	if typ != mqttConnack || len(resp) < 2 {
+		p.close()
+		return fmt.Errorf("%w: type %d", ErrMQTTPacket, typ)
+	}
+	if resp[1] != 0 {
+		p.close()
+		return fmt.Errorf("%w: return code %d", ErrMQTTRefused, resp[1])
+	}
+	return nil
+}
+
+// read reads a packet, returning its type and the rest of the packet after the fixed header.
+func (p *MQTTPublisher) read() (byte, []byte, error) {
+	first, err := p.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var n, shift int
+	for {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, ErrMQTTPacket
+		}
+	}
+	body := make([]byte, n)
+	if _, err = io.ReadFull(p.r, body); err != nil {
+		return 0, nil, err
+	}
+	return first >> 4, body, nil
+}
+
+// mqttPacket prefixes body with a fixed header made of first and the remaining length.
+func mqttPacket(first byte, body []byte) []byte {
+	b := []byte{first}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		if n /= 128; n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
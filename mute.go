@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+)
+
+// runMute implements the mute command, which stops the notifications about the shipments with the given tracking
+// numbers, or those selected by the flags of shipmentFilter, while they go on being tracked.
+func runMute(args []string) error {
+	return setMuted("mute", args, true)
+}
+
+// runUnmute implements the unmute command, which undoes mute.
+func runUnmute(args []string) error {
+	return setMuted("unmute", args, false)
+}
+
+func setMuted(name string, args []string, muted bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store (default $PARCEL_STORE, or store.json in the user data directory)")
+	filter := new(shipmentFilter)
+	filter.register(fs)
+	fs.Parse(args)
+
+	p, err := StorePath(*path)
+	if err != nil {
+		return err
+	}
+	store := NewFileStore(p)
+	ctx := context.Background()
+	shipments, err := store.ListShipments(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := selectKeys(shipments, filter, fs.Args())
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, key := range keys {
+		_, err = store.Update(ctx, key, func(sh *Shipment, exists bool) ([]Update, error) {
+			if !exists || sh.Muted == muted {
+				return nil, errUnchanged
+			}
+			sh.Muted = muted
+			changed++
+			return nil, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	info(name+"d shipments", "count", changed)
+	return nil
+}
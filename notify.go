@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrMatrix = errors.New("-matrix-homeserver, -matrix-room, and $PARCEL_MATRIX_TOKEN must all be set")
+
+// A Notifier tells a person or a service about a shipment whose status has changed.
+type Notifier interface {
+	Notify(ctx context.Context, res Result) error
+}
+
+// Notifiers are told about every changed result.
+var Notifiers []Notifier
+
+// notify passes res to every notifier, logging failures.
+func notify(ctx context.Context, res Result) {
+	for _, n := range Notifiers {
+		if err := n.Notify(ctx, res); err != nil {
+			warn("notification failed", "num", res.TrackingNum, "err", err)
+		}
+	}
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, res Result) error
+
+func (f NotifierFunc) Notify(ctx context.Context, res Result) error {
+	return f(ctx, res)
+}
+
+// send makes a request with a JSON body to a notification service and checks its status.
+func send(ctx context.Context, method, url string, header http.Header, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return sendRaw(ctx, method, url, header, "application/json", b)
+}
+
+// sendRaw is like send, but for a body that has already been encoded as contentType.
+func sendRaw(ctx context.Context, method, url string, header http.Header, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", PARCEL_USER_AGENT)
+	resp, err := ServiceClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// MatrixNotifier posts the summary of each result to a Matrix room as a notice.
+type MatrixNotifier struct {
+	Homeserver string // base URL, e.g. https://matrix.example.org
+	Token      string // access token of the account that posts
+	Room       string // room ID, e.g. !abc123:example.org
+}
+
+func (m *MatrixNotifier) Notify(ctx context.Context, res Result) error {
+	// the transaction ID makes retries of the same request idempotent
+	txn := make([]byte, 8)
+	if _, err := rand.Read(txn); err != nil {
+		return err
+	}
+	u := strings.TrimSuffix(m.Homeserver, "/") + "/_matrix/client/v3/rooms/" + url.PathEscape(m.Room) +
+		"/send/m.room.message/" + hex.EncodeToString(txn)
+	header := http.Header{"Authorization": {"Bearer " + m.Token}}
+	return send(ctx, http.MethodPut, u, header, map[string]string{"msgtype": "m.notice", "body": Summary(res)})
+}
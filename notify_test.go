@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatrixNotifier(t *testing.T) {
+	var got *http.Request
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"event_id":"$1"}`))
+	}))
+	defer srv.Close()
+
+	m := &MatrixNotifier{Homeserver: srv.URL + "/", Token: "tok", Room: "!abc:example.org"}
+	res := Result{TrackingNum: "9400100000000000000000", Carrier: USPS, State: DELIVERED, Delivered: true}
+	if err := m.Notify(context.Background(), res); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPut {
+		t.Errorf("method %s, want PUT", got.Method)
+	}
+	if prefix := "/_matrix/client/v3/rooms/!abc:example.org/send/m.room.message/"; !strings.HasPrefix(got.URL.Path, prefix) ||
+		len(got.URL.Path) == len(prefix) {
+		t.Errorf("path %s, want %s followed by a transaction ID", got.URL.Path, prefix)
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Bearer tok" {
+		t.Errorf("Authorization %q", auth)
+	}
+	if ua := got.Header.Get("User-Agent"); ua != PARCEL_USER_AGENT {
+		t.Errorf("User-Agent %q, want %q", ua, PARCEL_USER_AGENT)
+	}
+	if body["msgtype"] != "m.notice" || body["body"] != Summary(res) {
+		t.Errorf("body %v", body)
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	var titles []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		titles = append(titles, body["title"])
+	}))
+	defer srv.Close()
+
+	history, notifiers, rcpts := History, Notifiers, recipients
+	defer func() { History, Notifiers, recipients = history, notifiers, rcpts }()
+	History, Notifiers = NewFileStore(filepath.Join(t.TempDir(), "store.json")), nil
+	recipients = nil
+	if err := recipients.Set("Alice=json://" + strings.TrimPrefix(srv.URL, "http://") + "/pickup"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the recipient is kept in the store, and only told once, when the shipment is first seen delivered
+	res := Result{TrackingNum: "1Z999AA10123456784", Carrier: UPS, State: IN_TRANSIT, Label: "keyboard kits",
+		Updates: []Update{{DateTime: "2024-01-01T10:00:00Z", Location: "Louisville, KY", Status: "Departed"}}}
+	onResult(&res, true)
+	recipients = nil
+	delivered := res
+	delivered.State, delivered.Delivered, delivered.DeliveryDateTime = DELIVERED, true, "2024-01-02T10:00:00Z"
+	delivered.Updates = append([]Update{{DateTime: "2024-01-02T10:00:00Z", Location: "Brooklyn, NY", Status: "Delivered"}},
+		res.Updates...)
+	for i := 0; i < 2; i++ {
+		again := delivered
+		onResult(&again, true)
+	}
+	if want := "parcel: Ready for pickup by Alice: keyboard kits (UPS 1Z999AA10123456784)"; len(titles) != 1 ||
+		titles[0] != want {
+		t.Errorf("notified %q, want [%q]", titles, want)
+	}
+}
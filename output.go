@@ -0,0 +1,77 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+)
+
+// Output is the destination of parcel's output, optionally gzipped. Unless it is stdout or was opened for appending,
+// data is written to a temporary file in the same directory that replaces the destination only when Close is called,
+// so an interrupted or failed run never leaves a truncated file behind.
+type Output struct {
+	f    *os.File
+	gz   *gzip.Writer
+	dest string // the path that the temporary file is renamed to by Close; empty if writing directly
+}
+
+func (out *Output) Write(p []byte) (int, error) {
+	if out.gz != nil {
+		return out.gz.Write(p)
+	}
+	return out.f.Write(p)
+}
+
+// Close flushes and closes the output and, for atomic writes, moves the file into place.
+func (out *Output) Close() error {
+	if out.gz != nil {
+		if err := out.gz.Close(); err != nil {
+			out.Abort()
+			return err
+		}
+	}
+	if out.f == os.Stdout {
+		return nil
+	}
+	if err := out.f.Close(); err != nil {
+		out.Abort()
+		return err
+	}
+	if out.dest == "" {
+		return nil
+	}
+	if err := os.Rename(out.f.Name(), out.dest); err != nil {
+		os.Remove(out.f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort closes the output, discarding anything written to it unless it is stdout or was opened for appending.
+func (out *Output) Abort() {
+	if out.f == os.Stdout {
+		return
+	}
+	out.f.Close()
+	if out.dest != "" {
+		os.Remove(out.f.Name())
+	}
+}
+
+func createAtomic(s string) (*Output, error) {
+	f, err := os.CreateTemp(filepath.Dir(s), "."+filepath.Base(s)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	// CreateTemp uses 0600; keep the permissions of a file being replaced, or use the usual default
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(s); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err = f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return &Output{f: f, dest: s}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+)
+
+// runParse implements the parse command, which parses saved tracking pages without making any requests.
+func runParse(args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	num := fs.String("n", "", "tracking number to report in the result")
+	carrier := fs.String("c", "", "carrier to report in the result")
+	format := fs.String("format", "", "output format (default table when writing to a terminal, json otherwise)")
+	pretty := fs.Bool("pretty", false, "print the output json with indented fields")
+	tz := fs.String("tz", "", "the IANA time zone database location name to use when parsing date objects")
+	strict := fs.Bool("strict-dates", false, "fail if any date-time can't be normalized to RFC 3339")
+	fs.Parse(args)
+	StrictDates = *strict
+
+	f, err := ValidateFormat(*format)
+	if *format == "" {
+		f, err = JSON, nil
+	}
+	if err != nil {
+		return err
+	}
+	if *tz != "" {
+		if TZ, err = time.LoadLocation(*tz); err != nil {
+			return err
+		}
+	}
+	var cr Carrier
+	if *carrier != "" {
+		if cr, err = ValidateCarrier(*carrier); err != nil || cr == ANY {
+			return ErrCarrier
+		}
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	sinks, err := OpenSinks([]SinkSpec{{Path: STDOUT}}, f, *format != "", *pretty, len(paths) > 1, false, false)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		res, err := parseFile(path)
+		if err != nil {
+			return err
+		}
+		res.TrackingNum = *num
+		res.Carrier = cr
+		EnrichLocations(&res)
+		if err = sinks.Write(res); err != nil {
+			return err
+		}
+	}
+	return sinks.Flush()
+}
+
+func parseFile(path string) (Result, error) {
+	if path == "-" {
+		return Parse(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return *new(Result), err
+	}
+	defer f.Close()
+	return Parse(f)
+}
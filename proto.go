@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// protobuf wire types
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+)
+
+// MarshalProto encodes res as a length-delimited parcel.Result message, as defined in parcel.proto.
+func MarshalProto(res Result) []byte {
+	msg := appendResult(nil, res)
+	b := binary.AppendUvarint(make([]byte, 0, len(msg)+binary.MaxVarintLen64), uint64(len(msg)))
+	return append(b, msg...)
+}
+
+func appendResult(b []byte, res Result) []byte {
+	b = appendString(b, 1, res.TrackingNum)
+	b = appendString(b, 2, string(res.Carrier))
+	if res.Delivered {
+		b = appendTag(b, 3, wireVarint)
+		b = append(b, 1)
+	}
+	b = appendString(b, 4, res.DeliveryDateTime)
+	for _, u := range res.Updates {
+		b = appendBytes(b, 5, appendUpdate(nil, u))
+	}
+	b = appendString(b, 6, res.Layout)
+	b = appendString(b, 7, string(res.State))
+	b = appendString(b, 8, string(res.ETAConfidence))
+	for _, d := range res.Discrepancies {
+		b = appendString(b, 9, d)
+	}
+	if res.Error != nil {
+		e := appendString(nil, 1, res.Error.Code)
+		b = appendBytes(b, 10, appendString(e, 2, res.Error.Message))
+	}
+	for _, w := range res.Warnings {
+		b = appendString(b, 11, w)
+	}
+	b = appendString(b, 12, res.RawDeliveryDateTime)
+	if res.ETAAccuracyDays != nil {
+		b = appendTag(b, 13, wireVarint)
+		b = binary.AppendVarint(b, int64(*res.ETAAccuracyDays))
+	}
+	for _, t := range res.Tags {
+		b = appendString(b, 14, t)
+	}
+	b = appendString(b, 15, res.Label)
+	b = appendString(b, 16, res.Note)
+	return b
+}
+
+func appendUpdate(b []byte, u Update) []byte {
+	b = appendString(b, 1, u.DateTime)
+	b = appendString(b, 2, u.Location)
+	b = appendString(b, 3, u.Status)
+	if u.Facility != nil {
+		b = appendBytes(b, 4, appendFacility(nil, *u.Facility))
+	}
+	b = appendString(b, 5, u.RawDateTime)
+	if u.Coordinates != nil {
+		c := appendDouble(nil, 1, u.Coordinates.Latitude)
+		b = appendBytes(b, 6, appendDouble(c, 2, u.Coordinates.Longitude))
+	}
+	return b
+}
+
+func appendFacility(b []byte, f Facility) []byte {
+	b = appendString(b, 1, f.Name)
+	b = appendDouble(b, 2, f.Latitude)
+	return appendDouble(b, 3, f.Longitude)
+}
+
+func appendTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+// appendString omits empty strings, matching proto3 default value semantics.
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendBytes(b []byte, field int, p []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(p)))
+	return append(b, p...)
+}
+
+// appendDouble omits zero values, matching proto3 default value semantics.
+func appendDouble(b []byte, field int, f float64) []byte {
+	if f == 0 {
+		return b
+	}
+	b = appendTag(b, field, wire64)
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestMarshalProto checks MarshalProto against messages encoded by hand from parcel.proto.
+func TestMarshalProto(t *testing.T) {
+	days := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		res  Result
+		want string // the hex-encoded message, without its length prefix
+	}{
+		{"empty", Result{}, ""},
+		// tracking_num = 1, carrier = 2, delivered = 3
+		{"scalars", Result{TrackingNum: "1Z", Carrier: UPS, Delivered: true}, "0a02315a 1203555053 1801"},
+		// updates = 5; in Update, status = 3 and coordinates = 6, whose latitude = 1 is a double and longitude is left out
+		// as zero
+		{"update", Result{Updates: []Update{{Status: "x", Coordinates: &Coordinates{Latitude: 1.5}}}},
+			"2a0e 1a0178 3209 09000000000000f83f"},
+		// in Update, facility = 4; in Facility, name = 1 and longitude = 3
+		{"facility", Result{Updates: []Update{{Facility: &Facility{Name: "F", Longitude: -2}}}},
+			"2a0e 220c 0a0146 1900000000000000c0"},
+		// error = 10; in Error, code = 1 and message = 2
+		{"error", Result{Error: &LookupError{Code: "timeout", Message: "m"}}, "520c 0a0774696d656f7574 12016d"},
+		// eta_accuracy_days = 13 is an optional sint32, zigzag encoded, and present even when zero
+		{"early", Result{ETAAccuracyDays: days(-1)}, "6801"},
+		{"late", Result{ETAAccuracyDays: days(2)}, "6804"},
+		{"on time", Result{ETAAccuracyDays: days(0)}, "6800"},
+		// tags = 14, label = 15, and note = 16, whose tag takes two bytes
+		{"metadata", Result{Tags: []string{"a", "b"}, Label: "l", Note: "n"}, "720161 720162 7a016c 8201016e"},
+		// state = 7, eta_confidence = 8, discrepancies = 9, warnings = 11, raw_delivery_date_time = 12
+		{"strings", Result{State: DELIVERED, ETAConfidence: ETA_STALE, Discrepancies: []string{"d"}, Warnings: []string{"w"},
+			RawDeliveryDateTime: "r"}, "3a0964656c697665726564 42057374616c65 4a0164 5a0177 620172"},
+		// a label of 200 bytes has a two-byte length, as does the message
+		{"long", Result{Label: strings.Repeat("a", 200)}, "7ac801" + strings.Repeat("61", 200)},
+	}
+	for _, tt := range tests {
+		msg, err := hex.DecodeString(strings.ReplaceAll(tt.want, " ", ""))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := append(binary.AppendUvarint(nil, uint64(len(msg))), msg...)
+		if got := MarshalProto(tt.res); !bytes.Equal(got, want) {
+			t.Errorf("%s: got  %x\nwant %x", tt.name, got, want)
+		}
+	}
+}
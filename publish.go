@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"html/template"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runPublish implements the publish command, which renders the shipments in the store as a static HTML site: an
+// index and a page with the history of each shipment, to be shared through any static host. Tracking numbers, which
+// can reveal delivery addresses, are shown by their last four characters unless -show-numbers is set, and pages are
+// named by a hash of the shipment.
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to publish (default $PARCEL_STORE, or store.json in the user data directory)")
+	dir := fs.String("o", "site", "`directory` to write the site to")
+	title := fs.String("title", "Shipments", "title of the site")
+	show := fs.Bool("show-numbers", false, "show tracking numbers in full")
+	filter := new(shipmentFilter)
+	filter.register(fs)
+	fs.Parse(args)
+
+	p, err := StorePath(*path)
+	if err != nil {
+		return err
+	}
+	var store Store = NewFileStore(p)
+	if filter.active() {
+		if err = filter.validate(); err != nil {
+			return err
+		}
+		store = filteredStore{store, filter}
+	}
+	ctx := context.Background()
+	shipments, err := listShipments(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	site := publishedSite{Title: *title, Generated: time.Now().Format(TEXT_TIME)}
+	for _, sh := range shipments {
+		events, err := store.Events(ctx, sh.Key)
+		if err != nil {
+			return err
+		}
+		site.Shipments = append(site.Shipments, newPublishedShipment(sh, events, *show))
+	}
+	for _, ps := range site.Shipments {
+		if err = writeTemplate(filepath.Join(*dir, ps.Page), shipmentPageTemplate, struct {
+			Site     publishedSite
+			Shipment publishedShipment
+		}{site, ps}); err != nil {
+			return err
+		}
+	}
+	if err = writeTemplate(filepath.Join(*dir, "index.html"), indexPageTemplate, site); err != nil {
+		return err
+	}
+	info("published shipments", "count", len(site.Shipments), "dir", *dir)
+	return nil
+}
+
+type publishedSite struct {
+	Title     string
+	Generated string
+	Shipments []publishedShipment
+}
+
+// publishedShipment is a shipment as shown on the site, with its times formatted.
+type publishedShipment struct {
+	Page     string // file name of its page
+	Title    string
+	Carrier  Carrier
+	Number   string // masked unless -show-numbers
+	State    string
+	Latest   string // status and location of the most recent update
+	Delivery string // the (estimated) delivery date
+	Checked  string
+	Events   []publishedEvent
+}
+
+type publishedEvent struct {
+	Time, Location, Status string
+}
+
+func newPublishedShipment(sh Shipment, events []Update, show bool) publishedShipment {
+	id := sha256.Sum256([]byte(sh.Key.String()))
+	num := sh.TrackingNum
+	if !show && len(num) > 4 {
+		num = strings.Repeat("•", 4) + num[len(num)-4:]
+	}
+	ps := publishedShipment{
+		Page:    hex.EncodeToString(id[:8]) + ".html",
+		Title:   sh.Label,
+		Carrier: sh.Carrier,
+		Number:  num,
+		State:   strings.ReplaceAll(string(sh.Result.State), "_", " "),
+	}
+	if ps.Title == "" {
+		ps.Title = string(sh.Carrier) + " " + num
+	}
+	if ps.State == "" {
+		ps.State = "not checked"
+	}
+	if len(events) > 0 {
+		ps.Latest = events[0].Status
+		if events[0].Location != "" {
+			ps.Latest += ", " + events[0].Location
+		}
+	}
+	switch {
+	case sh.Result.Delivered:
+		ps.Delivery = "Delivered " + textTime(sh.Result.DeliveryDateTime)
+	case sh.Result.DeliveryDateTime != "":
+		ps.Delivery = "Expected " + textDate(sh.Result.DeliveryDateTime)
+	}
+	if !sh.Checked.IsZero() {
+		ps.Checked = sh.Checked.Local().Format(TEXT_TIME)
+	}
+	for _, u := range events {
+		ps.Events = append(ps.Events, publishedEvent{Time: textTime(u.DateTime), Location: u.Location, Status: u.Status})
+	}
+	return ps
+}
+
+func writeTemplate(path string, t *template.Template, data any) error {
+	b := new(bytes.Buffer)
+	if err := t.Execute(b, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b.Bytes())
+}
+
+const pageHead = `<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{block "title" .}}{{end}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #ddd; vertical-align: top; }
+.delivered { color: #1a7f37; } .stalled, .not.found { color: #b35900; }
+footer { margin-top: 2rem; color: #777; font-size: .85rem; }
+</style></head><body>
+`
+
+var indexPageTemplate = template.Must(template.New("index").Parse(pageHead + `{{define "title"}}{{.Title}}{{end}}
+<h1>{{.Title}}</h1>
+{{if .Shipments}}<table>
+<tr><th>Shipment</th><th>Carrier</th><th>Number</th><th>State</th><th>Latest update</th><th>Delivery</th></tr>
+{{range .Shipments}}<tr><td><a href="{{.Page}}">{{.Title}}</a></td><td>{{.Carrier}}</td><td>{{.Number}}</td><td class="{{.State}}">{{.State}}</td><td>{{.Latest}}</td><td>{{.Delivery}}</td></tr>
+{{end}}</table>{{else}}<p>No shipments.</p>{{end}}
+<footer>Updated {{.Generated}}</footer>
+</body></html>
+`))
+
+var shipmentPageTemplate = template.Must(template.New("shipment").Parse(pageHead + `{{define "title"}}{{.Shipment.Title}} - {{.Site.Title}}{{end}}
+{{with .Shipment}}<p><a href="index.html">{{$.Site.Title}}</a></p>
+<h1>{{.Title}}</h1>
+<p>{{.Carrier}} {{.Number}} &middot; <span class="{{.State}}">{{.State}}</span>{{if .Delivery}} &middot; {{.Delivery}}{{end}}</p>
+{{if .Events}}<table>
+<tr><th>Time</th><th>Location</th><th>Status</th></tr>
+{{range .Events}}<tr><td>{{.Time}}</td><td>{{.Location}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>{{else}}<p>No tracking updates yet.</p>{{end}}
+<footer>{{if .Checked}}Checked {{.Checked}}; {{end}}updated {{$.Site.Generated}}</footer>{{end}}
+</body></html>
+`))
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrQuery = errors.New("invalid query")
+
+// Query is the path expression used by the query output format.
+var Query []PathElem
+
+// PathElem is one step of a query path: a field name, an array index, or (with Each set) every array element.
+type PathElem struct {
+	Field string
+	Index int
+	Each  bool
+}
+
+// ParseQuery parses a jq-style path such as .updates[0].status, .updates[-1], or .updates[].location.
+func ParseQuery(s string) ([]PathElem, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, ".") {
+		return nil, ErrQuery
+	}
+	var path []PathElem
+	for s != "" && s != "." {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			i := strings.IndexAny(s, ".[")
+			if i < 0 {
+				i = len(s)
+			}
+			if i == 0 {
+				return nil, ErrQuery
+			}
+			path = append(path, PathElem{Field: s[:i]})
+			s = s[i:]
+		case '[':
+			i := strings.IndexByte(s, ']')
+			if i < 0 {
+				return nil, ErrQuery
+			}
+			if i == 1 {
+				path = append(path, PathElem{Each: true})
+			} else {
+				idx, err := strconv.Atoi(s[1:i])
+				if err != nil {
+					return nil, ErrQuery
+				}
+				path = append(path, PathElem{Index: idx})
+			}
+			s = s[i+1:]
+		default:
+			return nil, ErrQuery
+		}
+	}
+	return path, nil
+}
+
+// MarshalQuery prints the values selected by Query from the JSON form of res, one per line. Strings are printed
+// without quotes; other values, and values not present in the result, are printed as JSON.
+func MarshalQuery(res Result) ([]byte, error) {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	for _, val := range selectPath([]any{v}, Query) {
+		if s, ok := val.(string); ok {
+			out = append(out, s...)
+		} else {
+			enc, err := json.Marshal(val)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, enc...)
+		}
+		out = append(out, '\n')
+	}
+	return out, nil
+}
+
+func selectPath(vals []any, path []PathElem) []any {
+	for _, elem := range path {
+		var next []any
+		for _, v := range vals {
+			switch {
+			case elem.Field != "":
+				m, _ := v.(map[string]any)
+				next = append(next, m[elem.Field])
+			case elem.Each:
+				a, _ := v.([]any)
+				next = append(next, a...)
+			default:
+				a, _ := v.([]any)
+				i := elem.Index
+				if i < 0 {
+					i += len(a)
+				}
+				if i < 0 || i >= len(a) {
+					next = append(next, nil)
+					continue
+				}
+				next = append(next, a[i])
+			}
+		}
+		vals = next
+	}
+	return vals
+}
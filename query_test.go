@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []PathElem
+		err   error
+	}{
+		{".", nil, nil},
+		{" .delivered ", []PathElem{{Field: "delivered"}}, nil},
+		{".updates[0].status", []PathElem{{Field: "updates"}, {Index: 0}, {Field: "status"}}, nil},
+		{".updates[-1]", []PathElem{{Field: "updates"}, {Index: -1}}, nil},
+		{".updates[].location", []PathElem{{Field: "updates"}, {Each: true}, {Field: "location"}}, nil},
+		{".error.code", []PathElem{{Field: "error"}, {Field: "code"}}, nil},
+		{"delivered", nil, ErrQuery},
+		{"", nil, ErrQuery},
+		{"..delivered", nil, ErrQuery},
+		{".updates[0", nil, ErrQuery},
+		{".updates[x]", nil, ErrQuery},
+		{".updates[0]status", nil, ErrQuery},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.query)
+		if !errors.Is(err, tt.err) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, %v; want %+v, %v", tt.query, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestMarshalQuery(t *testing.T) {
+	res := Result{TrackingNum: "1Z999AA10123456784", Delivered: true, Updates: []Update{
+		{DateTime: "2024-01-02T10:00:00Z", Location: "Brooklyn, NY", Status: "Delivered"},
+		{DateTime: "2024-01-01T10:00:00Z", Location: "Louisville, KY", Status: "Departed"},
+	}}
+	q := Query
+	defer func() { Query = q }()
+	tests := []struct {
+		query, want string
+	}{
+		{".trackingNum", "1Z999AA10123456784\n"},
+		{".delivered", "true\n"},
+		{".updates[0].status", "Delivered\n"},
+		{".updates[-1].location", "Louisville, KY\n"},
+		{".updates[].location", "Brooklyn, NY\nLouisville, KY\n"},
+		{".updates[5].status", "null\n"},
+		{".label", "null\n"},
+	}
+	for _, tt := range tests {
+		var err error
+		if Query, err = ParseQuery(tt.query); err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalQuery(res)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.query, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces events at least Interval apart, no matter how many goroutines are waiting for them.
+type RateLimiter struct {
+	Interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // the earliest time of the next event
+}
+
+// Limiter limits the rate of requests to the source, if it is not nil.
+var Limiter *RateLimiter
+
+// NewRateLimiter returns a RateLimiter that allows rate events per second.
+func NewRateLimiter(rate float64) *RateLimiter {
+	return &RateLimiter{Interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// Wait blocks until the next event may happen or ctx is done. A nil RateLimiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.Interval)
+	l.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	debug("rate limited", "wait", wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var ErrRecipient = errors.New("invalid recipient; use name=url")
+
+// Recipient is someone whom the contents of a shipment are for, such as a member of a group buy, who is notified at
+// URL, a notification URL as for -notify, when the shipment is delivered and ready for pickup.
+type Recipient struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// recipientsFlag collects repeated -recipient flags of the form name=url. An empty url removes the recipient.
+type recipientsFlag []Recipient
+
+func (r *recipientsFlag) String() string {
+	names := make([]string, len(*r))
+	for i, rcpt := range *r {
+		names[i] = rcpt.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (r *recipientsFlag) Set(v string) error {
+	name, url, ok := strings.Cut(v, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return ErrRecipient
+	}
+	if url != "" {
+		if _, err := ParseNotifyURL(url); err != nil {
+			return err
+		}
+	}
+	*r = mergeRecipients(*r, []Recipient{{name, url}})
+	return nil
+}
+
+// mergeRecipients returns a with the recipients in b added, replacing those of the same name. Recipients in b with no
+// URL are removed.
+func mergeRecipients(a, b []Recipient) []Recipient {
+	if len(b) == 0 {
+		return a
+	}
+	out := slices.Clone(a)
+	for _, rcpt := range b {
+		out = slices.DeleteFunc(out, func(o Recipient) bool { return o.Name == rcpt.Name })
+		if rcpt.URL != "" {
+			out = append(out, rcpt)
+		}
+	}
+	return out
+}
+
+// notifyRecipients tells each recipient of the delivered shipment of res that it is ready for pickup.
+func notifyRecipients(ctx context.Context, res Result) {
+	for _, rcpt := range res.recipients {
+		n, err := ParseNotifyURL(rcpt.URL)
+		if err != nil {
+			warn("recipient notification failed", "num", res.TrackingNum, "recipient", rcpt.Name, "err", err)
+			continue
+		}
+		pickup := res
+		pickup.Label = "Ready for pickup by " + rcpt.Name
+		if res.Label != "" {
+			pickup.Label += ": " + res.Label
+		}
+		if err = n.Notify(ctx, pickup); err != nil {
+			warn("recipient notification failed", "num", res.TrackingNum, "recipient", rcpt.Name, "err", err)
+		}
+	}
+}
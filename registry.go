@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const REGISTRY_VERSION = 1
+
+var (
+	ErrRegistryCommand   = errors.New("unknown registry command; use export or import")
+	ErrRegistryVersion   = errors.New("unsupported registry version")
+	ErrRegistrySignature = errors.New("registry signature is missing or invalid")
+)
+
+// Registry is the portable document written by registry export: the shipments in a store and what is known about
+// them, but not their results or histories, which are fetched again, nor their recipients, whose notification URLs may
+// hold credentials. If $PARCEL_REGISTRY_KEY is set, the document is signed with the hex-encoded HMAC-SHA256, keyed
+// with it, of the document encoded as JSON without its signature, prefixed with "sha256=".
+type Registry struct {
+	Version   int             `json:"version"`
+	Exported  time.Time       `json:"exported"`
+	Shipments []RegistryEntry `json:"shipments"`
+	Signature string          `json:"signature,omitempty"`
+}
+
+type RegistryEntry struct {
+	Carrier     Carrier   `json:"carrier"`
+	TrackingNum string    `json:"trackingNum"`
+	Added       time.Time `json:"added"`
+	Merchant    string    `json:"merchant,omitempty"`
+	OrderID     string    `json:"orderId,omitempty"`
+	Label       string    `json:"label,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Note        string    `json:"note,omitempty"`
+}
+
+// runRegistry implements the registry command, whose export and import subcommands copy the shipments in a store to
+// and from a Registry document, e.g. to set up parcel on a new machine or to share a set of shipments.
+func runRegistry(args []string) error {
+	if len(args) == 0 {
+		fatalWith(EXIT_USAGE, ErrRegistryCommand.Error())
+	}
+	switch args[0] {
+	case "export":
+		return registryExport(args[1:])
+	case "import":
+		return registryImport(args[1:])
+	}
+	fatalWith(EXIT_USAGE, ErrRegistryCommand.Error()+": "+args[0])
+	return nil
+}
+
+func registryExport(args []string) error {
+	fs := flag.NewFlagSet("registry export", flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to export (default $PARCEL_STORE, or store.json in the user data directory)")
+	out := fs.String("o", "", "`path` to write the registry to (default <stdout>)")
+	filter := new(shipmentFilter)
+	filter.register(fs)
+	fs.Parse(args)
+
+	p, err := StorePath(*path)
+	if err != nil {
+		return err
+	}
+	var store Store = NewFileStore(p)
+	if filter.active() {
+		if err = filter.validate(); err != nil {
+			return err
+		}
+		store = filteredStore{store, filter}
+	}
+	shipments, err := listShipments(context.Background(), store)
+	if err != nil {
+		return err
+	}
+	reg := Registry{Version: REGISTRY_VERSION, Exported: time.Now().UTC(), Shipments: []RegistryEntry{}}
+	for _, sh := range shipments {
+		reg.Shipments = append(reg.Shipments, RegistryEntry{
+			Carrier:     sh.Carrier,
+			TrackingNum: sh.TrackingNum,
+			Added:       sh.Added,
+			Merchant:    sh.Merchant,
+			OrderID:     sh.OrderID,
+			Label:       sh.Label,
+			Tags:        sh.Tags,
+			Note:        sh.Note,
+		})
+	}
+	if key := os.Getenv("PARCEL_REGISTRY_KEY"); key != "" {
+		if reg.Signature, err = reg.sign([]byte(key)); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(reg, "", "\t")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	return writeFileAtomic(*out, b)
+}
+
+func registryImport(args []string) error {
+	fs := flag.NewFlagSet("registry import", flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to import into (default $PARCEL_STORE, or store.json in the user data directory)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fatalWith(EXIT_USAGE, ErrArgs.Error())
+	}
+
+	var b []byte
+	var err error
+	if name := fs.Arg(0); name == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+	reg, err := ParseRegistry(b, []byte(os.Getenv("PARCEL_REGISTRY_KEY")))
+	if err != nil {
+		return err
+	}
+	p, err := StorePath(*path)
+	if err != nil {
+		return err
+	}
+	n, err := reg.addTo(context.Background(), NewFileStore(p))
+	if err != nil {
+		return err
+	}
+	info("imported registry", "shipments", len(reg.Shipments), "added", n)
+	return nil
+}
+
+// ParseRegistry decodes a Registry document and, if key is set, checks its signature. A signed document is accepted
+// without a key, with a warning. Every shipment must have a carrier other than ANY and a valid tracking number.
+func ParseRegistry(b, key []byte) (Registry, error) {
+	var reg Registry
+	if err := json.Unmarshal(b, &reg); err != nil {
+		return *new(Registry), err
+	}
+	if reg.Version != REGISTRY_VERSION {
+		return *new(Registry), fmt.Errorf("%w: %d", ErrRegistryVersion, reg.Version)
+	}
+	switch {
+	case len(key) > 0:
+		want, err := reg.sign(key)
+		if err != nil {
+			return *new(Registry), err
+		}
+		if !hmac.Equal([]byte(reg.Signature), []byte(want)) {
+			return *new(Registry), ErrRegistrySignature
+		}
+	case reg.Signature != "":
+		warn("registry signature not checked; set $PARCEL_REGISTRY_KEY to check it")
+	}
+	for _, e := range reg.Shipments {
+		if c, err := ValidateCarrier(string(e.Carrier)); err != nil || c != e.Carrier || c == ANY {
+			return *new(Registry), fmt.Errorf("%w: %s", ErrCarrier, e.Carrier)
+		}
+		if num, err := SanitizeInput(e.TrackingNum); err != nil || num != e.TrackingNum {
+			return *new(Registry), fmt.Errorf("%w: %s", ErrNum, e.TrackingNum)
+		}
+	}
+	return reg, nil
+}
+
+// sign returns the signature of reg, which is computed without its Signature.
+func (reg Registry) sign(key []byte) (string, error) {
+	reg.Signature = ""
+	b, err := json.Marshal(reg)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// addTo adds the shipments in reg to store, and returns how many were new. The tags of shipments already in the store
+// are merged, and their label, note, merchant, and order ID are filled in where they are missing.
+func (reg Registry) addTo(ctx context.Context, store Store) (int, error) {
+	added := 0
+	for _, e := range reg.Shipments {
+		key := Key{Carrier: e.Carrier, TrackingNum: e.TrackingNum}
+		_, err := store.Update(ctx, key, func(sh *Shipment, exists bool) ([]Update, error) {
+			if !exists {
+				added++
+				*sh = Shipment{Key: key, Added: e.Added, Merchant: e.Merchant, OrderID: e.OrderID, Label: e.Label, Tags: e.Tags,
+					Note: e.Note}
+				return nil, nil
+			}
+			changed := !hasTags(sh.Tags, e.Tags)
+			sh.Tags = mergeTags(sh.Tags, e.Tags)
+			fill := func(dst *string, src string) {
+				if *dst == "" && src != "" {
+					*dst, changed = src, true
+				}
+			}
+			fill(&sh.Merchant, e.Merchant)
+			fill(&sh.OrderID, e.OrderID)
+			fill(&sh.Label, e.Label)
+			fill(&sh.Note, e.Note)
+			if !changed {
+				return nil, errUnchanged
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	key := []byte("secret")
+	reg := Registry{Version: REGISTRY_VERSION, Exported: time.Now(), Shipments: []RegistryEntry{
+		{Carrier: UPS, TrackingNum: "1Z999AA10123456784", Added: time.Now(), Label: "keyboard kits", Tags: []string{"groupbuy"}},
+	}}
+	var err error
+	if reg.Signature, err = reg.sign(key); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ParseRegistry(b, key); err != nil {
+		t.Errorf("signed with the key: %v", err)
+	}
+	if _, err = ParseRegistry(b, []byte("other")); !errors.Is(err, ErrRegistrySignature) {
+		t.Errorf("signed with another key: %v, want ErrRegistrySignature", err)
+	}
+	tampered := reg
+	tampered.Shipments = []RegistryEntry{{Carrier: UPS, TrackingNum: "1Z999AA10123456784", Label: "something else"}}
+	b, _ = json.Marshal(tampered)
+	if _, err = ParseRegistry(b, key); !errors.Is(err, ErrRegistrySignature) {
+		t.Errorf("tampered document: %v, want ErrRegistrySignature", err)
+	}
+	invalid := Registry{Version: REGISTRY_VERSION, Shipments: []RegistryEntry{{Carrier: ANY, TrackingNum: "1Z999AA10123456784"}}}
+	b, _ = json.Marshal(invalid)
+	if _, err = ParseRegistry(b, nil); !errors.Is(err, ErrCarrier) {
+		t.Errorf("shipment for ANY: %v, want ErrCarrier", err)
+	}
+
+	// a shipment already in the store keeps its label and gets the document's tags
+	store := NewMemStore()
+	k := Key{Carrier: UPS, TrackingNum: "1Z999AA10123456784"}
+	store.Put(context.Background(), Shipment{Key: k, Label: "mine", Tags: []string{"work"}})
+	if n, err := reg.addTo(context.Background(), store); err != nil || n != 0 {
+		t.Fatalf("addTo: %d, %v; want 0 added", n, err)
+	}
+	sh, _ := store.Get(context.Background(), k)
+	if sh.Label != "mine" || !hasTags(sh.Tags, []string{"groupbuy", "work"}) {
+		t.Errorf("merged %+v", sh)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HEADER_INSTANCE  = "X-Parcel-Instance"
+	HEADER_TIMESTAMP = "X-Parcel-Timestamp"
+	HEADER_SIGNATURE = "X-Parcel-Signature"
+)
+
+// SigningTransport identifies requests to a self-hosted relay by instance ID and, if Key is set, signs them so that the
+// relay can authenticate them. The signature is the hex-encoded HMAC-SHA256, keyed with Key, of the Unix timestamp
+// sent in X-Parcel-Timestamp, the request method, and the request URI, separated by newlines, prefixed with "sha256=".
+type SigningTransport struct {
+	Next     http.RoundTripper
+	Instance string
+	Key      []byte
+}
+
+func (t *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.Instance != "" {
+		req.Header.Set(HEADER_INSTANCE, t.Instance)
+	}
+	if len(t.Key) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HEADER_TIMESTAMP, ts)
+		req.Header.Set(HEADER_SIGNATURE, Sign(t.Key, ts, req.Method, req.URL.RequestURI()))
+	}
+	return t.Next.RoundTrip(req)
+}
+
+// Sign computes the value of the X-Parcel-Signature header.
+func Sign(key []byte, ts, method, uri string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts + "\n" + method + "\n" + uri))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
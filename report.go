@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	ErrReport       = errors.New("unknown report")
+	ErrReportFormat = errors.New("invalid report format")
+)
+
+// A report is computed from the shipments in the store. Its JSON form is the report itself; the other formats render
+// its rows as a table, and only text includes the summary.
+type report interface {
+	Rows() [][]string // the header, then one row per item
+	Summary() string  // a few lines of text to print after the table; may be empty
+}
+
+// reports, selected by the argument following report
+var reports = map[string]func(ctx context.Context, store Store) (report, error){
+	"eta": func(ctx context.Context, store Store) (report, error) {
+		shipments, err := listShipments(ctx, store)
+		return NewETAReport(shipments), err
+	},
+	"carriers": func(ctx context.Context, store Store) (report, error) {
+		return NewCarrierReport(ctx, store)
+	},
+}
+
+// runReport implements the report command, which prints a report on the shipments recorded in the store.
+func runReport(args []string) error {
+	if len(args) == 0 {
+		return ErrReport
+	}
+	build, ok := reports[args[0]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrReport, args[0])
+	}
+	return printReport("report "+args[0], build, args[1:], true)
+}
+
+// printReport parses the flags shared by reports from args, builds a report from the store, and prints it. archived
+// is the default for -archived.
+func printReport(name string, build func(ctx context.Context, store Store) (report, error), args []string, archived bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to report on (default $PARCEL_STORE, or store.json in the user data directory)")
+	format := fs.String("format", "text", "output format: text, json, csv, or markdown")
+	filter := new(shipmentFilter)
+	filter.register(fs)
+	fs.BoolVar(&archived, "archived", archived, "include archived shipments")
+	fs.Parse(args)
+
+	p, err := StorePath(*path)
+	if err != nil {
+		return err
+	}
+	var store Store = NewFileStore(p)
+	if archived {
+		store = archivedStore{store}
+	}
+	if filter.active() {
+		if err = filter.validate(); err != nil {
+			return err
+		}
+		store = filteredStore{store, filter}
+	}
+	r, err := build(context.Background(), store)
+	if err != nil {
+		return err
+	}
+	return writeReport(os.Stdout, r, *format)
+}
+
+// listShipments returns the shipments in store in the order that they were added.
+func listShipments(ctx context.Context, store Store) ([]Shipment, error) {
+	shipments, err := store.ListShipments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(shipments, func(i, j int) bool {
+		return shipments[i].Added.Before(shipments[j].Added)
+	})
+	return shipments, nil
+}
+
+func writeReport(w io.Writer, r report, format string) error {
+	switch format {
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, row := range r.Rows() {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if s := r.Summary(); s != "" {
+			_, err := io.WriteString(w, "\n"+s)
+			return err
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(r)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.WriteAll(r.Rows())
+		return cw.Error()
+	case "markdown":
+		b := new(strings.Builder)
+		for i, row := range r.Rows() {
+			for _, cell := range row {
+				b.WriteString("| " + mdEscape(cell) + " ")
+			}
+			b.WriteString("|\n")
+			if i == 0 {
+				b.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrReportFormat, format)
+}
+
+// ETAOutcome compares the first estimated delivery date seen for a shipment with its delivery.
+type ETAOutcome struct {
+	Key
+	Label     string `json:"label,omitempty"`
+	Promised  string `json:"promised"`
+	Delivered string `json:"delivered"`
+	Days      int    `json:"days"` // positive if late, negative if early
+}
+
+// ETATally counts the delivered shipments of a carrier, or of all carriers, by whether they arrived by the first
+// estimated delivery date.
+type ETATally struct {
+	Delivered int     `json:"delivered"`
+	Early     int     `json:"early"`
+	OnTime    int     `json:"onTime"`
+	Late      int     `json:"late"`
+	MeanDays  float64 `json:"meanDays"`
+}
+
+func (t *ETATally) add(days int) {
+	t.MeanDays = (t.MeanDays*float64(t.Delivered) + float64(days)) / float64(t.Delivered+1)
+	t.Delivered++
+	switch {
+	case days < 0:
+		t.Early++
+	case days == 0:
+		t.OnTime++
+	default:
+		t.Late++
+	}
+}
+
+func (t ETATally) String() string {
+	return fmt.Sprintf("%d delivered: %d early, %d on time, %d late; %+.1f days on average",
+		t.Delivered, t.Early, t.OnTime, t.Late, t.MeanDays)
+}
+
+// ETAReport compares promised and actual delivery dates across the delivered shipments that had an estimated
+// delivery date when they were first recorded.
+type ETAReport struct {
+	Shipments []ETAOutcome          `json:"shipments"`
+	Total     ETATally              `json:"total"`
+	Carriers  map[Carrier]*ETATally `json:"carriers"`
+}
+
+func NewETAReport(shipments []Shipment) *ETAReport {
+	r := &ETAReport{Shipments: []ETAOutcome{}, Carriers: map[Carrier]*ETATally{}}
+	for _, sh := range shipments {
+		if !sh.Result.Delivered {
+			continue
+		}
+		days, ok := ETAAccuracyDays(sh.FirstETA, sh.Result.DeliveryDateTime)
+		if !ok {
+			continue
+		}
+		r.Shipments = append(r.Shipments, ETAOutcome{
+			Key:       sh.Key,
+			Label:     sh.Label,
+			Promised:  sh.FirstETA,
+			Delivered: sh.Result.DeliveryDateTime,
+			Days:      days,
+		})
+		r.Total.add(days)
+		if r.Carriers[sh.Carrier] == nil {
+			r.Carriers[sh.Carrier] = new(ETATally)
+		}
+		r.Carriers[sh.Carrier].add(days)
+	}
+	return r
+}
+
+func (r *ETAReport) Rows() [][]string {
+	rows := [][]string{{"CARRIER", "TRACKING NUMBER", "LABEL", "PROMISED", "DELIVERED", "DAYS LATE"}}
+	for _, o := range r.Shipments {
+		rows = append(rows, []string{string(o.Carrier), o.TrackingNum, o.Label, textDate(o.Promised), textDate(o.Delivered), strconv.Itoa(o.Days)})
+	}
+	return rows
+}
+
+func (r *ETAReport) Summary() string {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "all carriers: %s\n", r.Total)
+	for _, c := range Carriers {
+		if t := r.Carriers[c]; t != nil {
+			fmt.Fprintf(b, "%s: %s\n", c, t)
+		}
+	}
+	return b.String()
+}
+
+// CarrierPerformance summarizes how a carrier has handled the shipments in the store.
+type CarrierPerformance struct {
+	Carrier            Carrier `json:"carrier"`
+	Shipments          int     `json:"shipments"`
+	Delivered          int     `json:"delivered"`
+	OnTime             int     `json:"onTime"`     // delivered by the first estimated delivery date
+	OnTimeRate         float64 `json:"onTimeRate"` // of the delivered shipments with an estimated delivery date, or 0 if there are none
+	Exceptions         int     `json:"exceptions"` // shipments with at least one exception update
+	ExceptionRate      float64 `json:"exceptionRate"`
+	UpdatesPerShipment float64 `json:"updatesPerShipment"`
+	withETA            int
+	updates            int
+}
+
+// CarrierReport ranks carriers by their on-time rate, then by their exception rate. Carriers without any delivered
+// shipments with an estimated delivery date come last.
+type CarrierReport struct {
+	Carriers []*CarrierPerformance `json:"carriers"`
+}
+
+func NewCarrierReport(ctx context.Context, store Store) (*CarrierReport, error) {
+	shipments, err := listShipments(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	r := &CarrierReport{Carriers: []*CarrierPerformance{}}
+	byCarrier := map[Carrier]*CarrierPerformance{}
+	for _, sh := range shipments {
+		events, err := store.Events(ctx, sh.Key)
+		if err != nil {
+			return nil, err
+		}
+		p := byCarrier[sh.Carrier]
+		if p == nil {
+			p = &CarrierPerformance{Carrier: sh.Carrier}
+			byCarrier[sh.Carrier] = p
+			r.Carriers = append(r.Carriers, p)
+		}
+		p.Shipments++
+		p.updates += len(events)
+		for _, u := range events {
+			if IsException(u.Status) {
+				p.Exceptions++
+				break
+			}
+		}
+		if !sh.Result.Delivered {
+			continue
+		}
+		p.Delivered++
+		if days, ok := ETAAccuracyDays(sh.FirstETA, sh.Result.DeliveryDateTime); ok {
+			p.withETA++
+			if days <= 0 {
+				p.OnTime++
+			}
+		}
+	}
+	for _, p := range r.Carriers {
+		if p.withETA > 0 {
+			p.OnTimeRate = float64(p.OnTime) / float64(p.withETA)
+		}
+		p.ExceptionRate = float64(p.Exceptions) / float64(p.Shipments)
+		p.UpdatesPerShipment = float64(p.updates) / float64(p.Shipments)
+	}
+	sort.SliceStable(r.Carriers, func(i, j int) bool {
+		a, b := r.Carriers[i], r.Carriers[j]
+		if (a.withETA > 0) != (b.withETA > 0) {
+			return a.withETA > 0
+		}
+		if a.OnTimeRate != b.OnTimeRate {
+			return a.OnTimeRate > b.OnTimeRate
+		}
+		return a.ExceptionRate < b.ExceptionRate
+	})
+	return r, nil
+}
+
+func (r *CarrierReport) Rows() [][]string {
+	rows := [][]string{{"RANK", "CARRIER", "SHIPMENTS", "DELIVERED", "ON TIME", "EXCEPTIONS", "UPDATES PER SHIPMENT"}}
+	percent := func(f float64) string { return strconv.FormatFloat(f*100, 'f', 1, 64) + "%" }
+	for i, p := range r.Carriers {
+		onTime := "-"
+		if p.withETA > 0 {
+			onTime = percent(p.OnTimeRate)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			string(p.Carrier),
+			strconv.Itoa(p.Shipments),
+			strconv.Itoa(p.Delivered),
+			onTime,
+			percent(p.ExceptionRate),
+			strconv.FormatFloat(p.UpdatesPerShipment, 'f', 1, 64),
+		})
+	}
+	return rows
+}
+
+func (r *CarrierReport) Summary() string {
+	return "on time: delivered by the first estimated delivery date; exceptions: shipments with at least one exception update\n"
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SavePage writes a raw tracking page to dir, named by tracking number and the current time.
+func SavePage(dir, num string, page []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := num + "-" + time.Now().UTC().Format("20060102T150405.000Z") + ".html"
+	return os.WriteFile(filepath.Join(dir, name), page, 0644)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// POLL_HISTORY is the number of recent polls by -watch that are kept for each shipment.
+const POLL_HISTORY = 10
+
+// Poll is the outcome of a poll of a shipment by -watch.
+type Poll struct {
+	Time    time.Time `json:"time"`
+	Changed bool      `json:"changed,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// recordPoll records a poll of the shipment of res in the history, if any, along with when the next one is due; next
+// is zero once the shipment has been delivered. Shipments that aren't in the store aren't added.
+func recordPoll(res Result, poll Poll, next time.Time) {
+	if History == nil || res.Carrier == ANY {
+		return
+	}
+	key := Key{Carrier: res.Carrier, TrackingNum: res.TrackingNum}
+	_, err := History.Update(context.Background(), key, func(sh *Shipment, exists bool) ([]Update, error) {
+		if !exists {
+			return nil, errUnchanged
+		}
+		sh.NextPoll = next
+		sh.Polls = append([]Poll{poll}, sh.Polls[:min(len(sh.Polls), POLL_HISTORY-1)]...)
+		return nil, nil
+	})
+	if err != nil {
+		warn("recording poll failed", "num", res.TrackingNum, "err", err)
+	}
+}
+
+// runSchedule implements the schedule command, which prints when -watch last polled each undelivered shipment in
+// the store, how those polls went, and when the next one is due, to check that it is polling as expected.
+func runSchedule(args []string) error {
+	return printReport("schedule", func(ctx context.Context, store Store) (report, error) {
+		shipments, err := listShipments(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+		s := &Schedule{Shipments: []ScheduledShipment{}, now: time.Now()}
+		for _, sh := range shipments {
+			if !sh.Result.Delivered {
+				s.Shipments = append(s.Shipments, ScheduledShipment{Key: sh.Key, Label: sh.Label, State: sh.Result.State,
+					NextPoll: sh.NextPoll, Polls: sh.Polls})
+			}
+		}
+		return s, nil
+	}, args, false)
+}
+
+// Schedule is the polling schedule of the undelivered shipments in the store.
+type Schedule struct {
+	Shipments []ScheduledShipment `json:"shipments"`
+
+	now time.Time
+}
+
+type ScheduledShipment struct {
+	Key
+	Label    string    `json:"label,omitempty"`
+	State    State     `json:"state,omitempty"`
+	NextPoll time.Time `json:"nextPoll,omitempty"`
+	Polls    []Poll    `json:"polls,omitempty"` // most recent first
+}
+
+func (s *Schedule) Rows() [][]string {
+	rows := [][]string{{"CARRIER", "TRACKING NUMBER", "LABEL", "STATE", "LAST POLL", "NEXT POLL", "RECENT POLLS"}}
+	for _, sh := range s.Shipments {
+		last, next, recent := "-", "-", "-"
+		if len(sh.Polls) > 0 {
+			last = sh.Polls[0].Time.Local().Format(time.DateTime)
+			recent = pollsSummary(sh.Polls)
+		}
+		if !sh.NextPoll.IsZero() {
+			next = sh.NextPoll.Local().Format(time.DateTime)
+			if sh.NextPoll.Before(s.now) {
+				next += " (overdue)"
+			}
+		}
+		rows = append(rows, []string{string(sh.Carrier), sh.TrackingNum, sh.Label, string(sh.State), last, next, recent})
+	}
+	return rows
+}
+
+func (s *Schedule) Summary() string {
+	overdue := 0
+	for _, sh := range s.Shipments {
+		if !sh.NextPoll.IsZero() && sh.NextPoll.Before(s.now) {
+			overdue++
+		}
+	}
+	if overdue == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d polls overdue; is parcel -watch running?\n", overdue, len(s.Shipments))
+}
+
+// pollsSummary counts the outcomes of polls, and gives the most recent error, e.g. "8 ok (2 changed), 2 failed:
+// rate limited".
+func pollsSummary(polls []Poll) string {
+	ok, changed, failed, lastErr := 0, 0, 0, ""
+	for _, p := range polls {
+		switch {
+		case p.Error != "":
+			failed++
+			if lastErr == "" {
+				lastErr = p.Error
+			}
+		case p.Changed:
+			changed++
+			fallthrough
+		default:
+			ok++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d ok", ok)}
+	if changed > 0 {
+		parts[0] += fmt.Sprintf(" (%d changed)", changed)
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed: %s", failed, lastErr))
+	}
+	return strings.Join(parts, ", ")
+}
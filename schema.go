@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// schemaEnums lists the values of the string types that are enumerations.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(Carrier("")):       {string(DHL), string(FEDEX), string(USPS), string(UPS), string(ANY)},
+	reflect.TypeOf(ETAConfidence("")): {string(ETA_SOURCE), string(ETA_HEURISTIC), string(ETA_STALE)},
+	reflect.TypeOf(State("")):         {string(NOT_FOUND), string(PRE_TRANSIT), string(IN_TRANSIT), string(STALLED), string(DELIVERED)},
+}
+
+// schemaDescriptions documents the fields of the output, keyed by type and JSON field name.
+var schemaDescriptions = map[string]string{
+	"Result.trackingNum":         "The tracking number, with any characters other than letters and digits removed.",
+	"Result.deliveryDateTime":    "The delivery date-time if delivered, otherwise the estimated delivery date. Formatted as RFC 3339 if parcel is able to parse it; otherwise the string reported by the source.",
+	"Result.updates":             "The most recent tracking updates, most recent first.",
+	"Result.error":               "Set, instead of the other fields, if the tracking number could not be tracked.",
+	"LookupError.code":           "timeout, network, rate_limited (HTTP 429), rejected (other 4xx statuses), upstream (5xx statuses), source_down (not sent because the source has been failing), not_recorded (no response saved for -replay), date (an unparsable date-time with -strict-dates), or internal.",
+	"Result.rawDeliveryDateTime": "Set to deliveryDateTime if it could not be normalized to RFC 3339, in which case it should not be trusted as a timestamp.",
+	"Update.rawDateTime":         "Set to dateTime if it could not be normalized to RFC 3339, in which case it should not be trusted as a timestamp.",
+	"Result.warnings":            "The parts of the page that could not be parsed, such as dates in an unknown format or a missing delivery date banner.",
+	"Result.discrepancies":       "Disagreements between the primary source and the source given by -verify, if any.",
+	"Result.layout":              "The page layout variant that the result was parsed from.",
+	"Result.etaConfidence":       "How far the estimated delivery date can be trusted: source if reported by the source alongside recent scans, heuristic if recovered by the fallback text layout, or stale if the estimate has passed or the parcel has not been scanned in three days.",
+	"Result.state":               "Where the shipment is. pre_transit means that a label has been created but the carrier has not scanned the parcel yet; stalled means that it is in transit but has not had an update in the days given by -stall-days.",
+	"Result.etaAccuracyDays":     "Once delivered, the number of days between the first estimated delivery date recorded in the store and the delivery: positive if it was late, negative if it was early.",
+	"Result.tags":                "Labels for organizing shipments, given by -tag along with those recorded for the shipment in the store.",
+	"Result.label":               "A friendly name for the shipment, such as what is in it, given by -label or imported along with the tracking number, and kept in the store.",
+	"Result.note":                "A free-text note on the shipment, given by -note and kept in the store.",
+	"Update.facility":            "The carrier facility that location refers to, if parcel knows it.",
+	"Update.coordinates":         "Where location is, with -geocode: the coordinates of the facility, if known, or else those found by the geocoder.",
+	"Update.dateTime":            "Formatted as RFC 3339 if parcel is able to parse it; otherwise the string reported by the source.",
+}
+
+// Schema returns a JSON Schema (draft 2020-12) describing the JSON form of Result.
+func Schema() map[string]any {
+	defs := make(map[string]any)
+	schema := schemaFor(reflect.TypeOf(Result{}), defs)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Result"
+	schema["$defs"] = defs
+	return schema
+}
+
+// schemaFor describes t, adding the structs that it refers to, other than Result, to defs.
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), defs)
+	case reflect.Struct:
+		if t != reflect.TypeOf(Result{}) {
+			if _, ok := defs[t.Name()]; !ok {
+				defs[t.Name()] = nil // reserve the name in case of recursion
+				defs[t.Name()] = structSchema(t, defs)
+			}
+			return map[string]any{"$ref": "#/$defs/" + t.Name()}
+		}
+		return structSchema(t, defs)
+	}
+	return map[string]any{}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		prop := schemaFor(field.Type, defs)
+		if desc, ok := schemaDescriptions[t.Name()+"."+name]; ok {
+			prop["description"] = desc
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// runSchema implements the schema command, which prints the JSON Schema of the output.
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	fs.Parse(args)
+	b, err := json.MarshalIndent(Schema(), "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(b, '\n'))
+	return err
+}
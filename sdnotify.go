@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SdNotify sends state, such as READY=1, to the service manager over $NOTIFY_SOCKET, as systemd expects from
+// services of Type=notify. It does nothing if parcel wasn't started by such a service manager.
+func SdNotify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	// a leading @ names a socket in the abstract namespace, which net understands
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// WatchdogInterval returns how often to send WATCHDOG=1 to keep systemd's watchdog from restarting parcel: half of
+// $WATCHDOG_USEC, or 0 if the watchdog is disabled or meant for another process.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+func sdNotify(state string) {
+	if err := SdNotify(state); err != nil {
+		warn("notifying the service manager failed", "state", state, "err", err)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrShopifyToken = errors.New("$PARCEL_SHOPIFY_TOKEN is not set")
+
+// SHOPIFY_API_VERSION is the default version of the Shopify Admin API to request.
+const SHOPIFY_API_VERSION = "2024-10"
+
+// the next page in a Link header
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type shopifyOrder struct {
+	Name         string `json:"name"` // e.g. #1001
+	Fulfillments []struct {
+		Status          string   `json:"status"`          // success, cancelled, error, ...
+		ShipmentStatus  *string  `json:"shipment_status"` // delivered, in_transit, ..., or null
+		TrackingCompany string   `json:"tracking_company"`
+		TrackingNumbers []string `json:"tracking_numbers"`
+		LineItems       []struct {
+			Name string `json:"name"`
+		} `json:"line_items"`
+	} `json:"fulfillments"`
+}
+
+// importShopify reads the tracking numbers of the undelivered fulfillments of a Shopify store's recent orders through
+// the Admin API, labeling each with its order name and the items in it. The store is given by its name, its
+// myshopify.com domain, or a base URL, and the Admin API access token, which needs the read_orders scope, by
+// $PARCEL_SHOPIFY_TOKEN.
+func importShopify(args []string) ([]Job, error) {
+	fs := flag.NewFlagSet("import shopify", flag.ExitOnError)
+	since := fs.Int("since", 30, "scan orders updated in the last `days` days")
+	version := fs.String("api-version", SHOPIFY_API_VERSION, "Admin API `version`")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return nil, errors.New("no store given")
+	}
+	token := os.Getenv("PARCEL_SHOPIFY_TOKEN")
+	if token == "" {
+		return nil, ErrShopifyToken
+	}
+
+	base := fs.Arg(0)
+	if !strings.Contains(base, "://") {
+		if !strings.Contains(base, ".") {
+			base += ".myshopify.com"
+		}
+		base = "https://" + base
+	}
+	q := url.Values{
+		"status":         {"any"},
+		"updated_at_min": {time.Now().AddDate(0, 0, -*since).Format(time.RFC3339)},
+		"fields":         {"name,fulfillments"},
+		"limit":          {"250"},
+	}
+	next := strings.TrimSuffix(base, "/") + "/admin/api/" + *version + "/orders.json?" + q.Encode()
+
+	var jobs []Job
+	for next != "" {
+		var orders []shopifyOrder
+		var err error
+		if orders, next, err = shopifyOrders(context.Background(), next, token); err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			jobs = append(jobs, shopifyJobs(order)...)
+		}
+	}
+	return dedupeJobs(jobs), nil
+}
+
+// shopifyOrders fetches a page of orders and returns them with the URL of the next page, if there is one.
+func shopifyOrders(ctx context.Context, u, token string) ([]shopifyOrder, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", PARCEL_USER_AGENT)
+	req.Header.Set("X-Shopify-Access-Token", token)
+	resp, err := ServiceClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	var page struct {
+		Orders []shopifyOrder `json:"orders"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", err
+	}
+	var next string
+	if m := nextLinkPattern.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		next = m[1]
+	}
+	return page.Orders, next, nil
+}
+
+// shopifyJobs returns the tracking numbers of the order's fulfillments that have shipped but not been delivered.
+func shopifyJobs(order shopifyOrder) []Job {
+	var jobs []Job
+	for _, f := range order.Fulfillments {
+		if f.Status != "success" || (f.ShipmentStatus != nil && *f.ShipmentStatus == "delivered") {
+			continue
+		}
+		var items []string
+		for _, item := range f.LineItems {
+			items = append(items, item.Name)
+		}
+		for _, num := range f.TrackingNumbers {
+			num = strings.ToUpper(strings.ReplaceAll(num, " ", ""))
+			carrier, ok := shopifyCarrier(f.TrackingCompany)
+			if !ok {
+				if carrier, ok = DetectCarrier(num); !ok {
+					debug("skipping untrackable fulfillment", "order", order.Name, "num", num, "company", f.TrackingCompany)
+					continue
+				}
+			}
+			jobs = append(jobs, Job{Num: num, Carrier: carrier, OrderID: order.Name, Label: strings.Join(items, "; ")})
+		}
+	}
+	return jobs
+}
+
+// shopifyCarrier maps Shopify's tracking company names, such as "FedEx" or "DHL Express", to carriers.
+func shopifyCarrier(company string) (Carrier, bool) {
+	company = strings.ToUpper(strings.ReplaceAll(company, " ", ""))
+	for _, c := range []Carrier{USPS, UPS, FEDEX, DHL} {
+		if strings.HasPrefix(company, string(c)) {
+			return c, true
+		}
+	}
+	return *new(Carrier), false
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+const STDOUT = "<stdout>"
+
+// SinkSpec is an output destination given by -o, written as [format=]path. A path of - means stdout.
+type SinkSpec struct {
+	Format string
+	Path   string
+}
+
+// sinkFlag collects repeated -o flags.
+type sinkFlag []SinkSpec
+
+func (s *sinkFlag) String() string {
+	specs := make([]string, 0, len(*s))
+	for _, spec := range *s {
+		if spec.Format != "" {
+			specs = append(specs, spec.Format+"="+spec.Path)
+		} else {
+			specs = append(specs, spec.Path)
+		}
+	}
+	return strings.Join(specs, ", ")
+}
+
+func (s *sinkFlag) Set(v string) error {
+	spec := SinkSpec{Path: v}
+	if f, path, ok := strings.Cut(v, "="); ok {
+		if _, err := ValidateFormat(f); err == nil {
+			spec = SinkSpec{Format: f, Path: path}
+		}
+	}
+	if spec.Path == "-" || spec.Path == "" {
+		spec.Path = STDOUT
+	}
+	*s = append(*s, spec)
+	return nil
+}
+
+// Sink is an open output destination along with the writer for its format.
+type Sink struct {
+	out *Output
+	rw  *ResultWriter
+}
+
+// Sinks fan each result out to every output.
+type Sinks []Sink
+
+// OpenSinks opens every output in specs. Outputs without their own format use def, except that table is used in place
+// of an unspecified format for a terminal.
+func OpenSinks(specs []SinkSpec, def Format, defSet, pretty, batch, appnd, compress bool) (Sinks, error) {
+	var sinks Sinks
+	for _, spec := range specs {
+		f := def
+		if spec.Format != "" {
+			f, _ = ValidateFormat(spec.Format)
+		} else if !defSet && spec.Path == STDOUT && IsTerminal(os.Stdout) {
+			f = TABLE
+		}
+		if f == TEMPLATE && Template == nil {
+			sinks.Abort()
+			return nil, ErrTemplate
+		}
+
+		out, err := OutFile(spec.Path, appnd, compress)
+		if err != nil {
+			sinks.Abort()
+			return nil, err
+		}
+		rw := NewResultWriter(out, f, pretty, batch)
+		if f == TABLE && spec.Path == STDOUT && !compress && IsTerminal(os.Stdout) {
+			rw.width = TerminalWidth(os.Stdout)
+			rw.color = os.Getenv("NO_COLOR") == ""
+		}
+		sinks = append(sinks, Sink{out: out, rw: rw})
+	}
+	return sinks, nil
+}
+
+// Write writes res to every sink.
+func (s Sinks) Write(res Result) error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.rw.Write(res))
+	}
+	return errors.Join(errs...)
+}
+
+// Flush writes the results collected by every sink.
+func (s Sinks) Flush() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.rw.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink.
+func (s Sinks) Close() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.out.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Abort discards the output of every sink.
+func (s Sinks) Abort() {
+	for _, sink := range s {
+		sink.out.Abort()
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrStallDays = errors.New("invalid stall days")
+
+// DefaultStallDays is the number of days a shipment in transit can go without a new update before it is considered
+// stalled, for carriers that have no entry in StallDays.
+var (
+	DefaultStallDays = 5
+	StallDays        = map[Carrier]int{}
+)
+
+// ParseStallDays parses a comma-separated list of day counts, each optionally prefixed by a carrier and =, e.g.
+// "5,USPS=7,UPS=3". A count without a carrier sets the default.
+func ParseStallDays(s string) (def int, days map[Carrier]int, err error) {
+	def, days = DefaultStallDays, map[Carrier]int{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		carrier, count, ok := strings.Cut(field, "=")
+		if !ok {
+			carrier, count = "", field
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n <= 0 {
+			return def, days, fmt.Errorf("%w: %s", ErrStallDays, field)
+		}
+		if carrier == "" {
+			def = n
+			continue
+		}
+		c, err := ValidateCarrier(strings.TrimSpace(carrier))
+		if err != nil || c == ANY {
+			return def, days, fmt.Errorf("%w: %s", ErrStallDays, field)
+		}
+		days[c] = n
+	}
+	return def, days, nil
+}
+
+// Stalled reports whether res is in transit but has gone longer than the stall days for its carrier without a new
+// update as of now.
+func Stalled(res Result, now time.Time) bool {
+	if res.State != IN_TRANSIT || len(res.Updates) == 0 {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, res.Updates[0].DateTime)
+	if err != nil {
+		return false
+	}
+	days, ok := StallDays[res.Carrier]
+	if !ok {
+		days = DefaultStallDays
+	}
+	return now.Sub(last) > time.Duration(days)*24*time.Hour
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runStats implements the stats command, which prints transit time statistics for the delivered shipments in the
+// store.
+func runStats(args []string) error {
+	return printReport("stats", func(ctx context.Context, store Store) (report, error) {
+		return NewTransitStats(ctx, store)
+	}, args, true)
+}
+
+// Transit is the time that a delivered shipment spent in transit, from its first scan to its delivery.
+type Transit struct {
+	Key
+	Lane      string    `json:"lane"` // origin → destination
+	Scanned   time.Time `json:"scanned"`
+	Delivered time.Time `json:"delivered"`
+	Days      float64   `json:"days"`
+}
+
+// TransitGroup summarizes the transit times of a carrier's shipments, on one lane or all of them.
+type TransitGroup struct {
+	Carrier   Carrier `json:"carrier"`
+	Lane      string  `json:"lane,omitempty"` // empty for all of the carrier's lanes
+	Shipments int     `json:"shipments"`
+	MeanDays  float64 `json:"meanDays"`
+	P50Days   float64 `json:"p50Days"`
+	P90Days   float64 `json:"p90Days"`
+	P95Days   float64 `json:"p95Days"`
+	Weekdays  [7]int  `json:"weekdays"` // deliveries by day of the week, from Sunday
+	days      []float64
+}
+
+func (g *TransitGroup) add(t Transit) {
+	g.Shipments++
+	g.days = append(g.days, t.Days)
+	g.Weekdays[t.Delivered.Weekday()]++
+}
+
+func (g *TransitGroup) finish() {
+	sort.Float64s(g.days)
+	var sum float64
+	for _, d := range g.days {
+		sum += d
+	}
+	g.MeanDays = sum / float64(len(g.days))
+	g.P50Days = percentile(g.days, 50)
+	g.P90Days = percentile(g.days, 90)
+	g.P95Days = percentile(g.days, 95)
+}
+
+// percentile returns the pth percentile of sorted, by the nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// TransitStats summarizes the transit times of the delivered shipments in a store, by carrier and by lane.
+type TransitStats struct {
+	Shipments []Transit       `json:"shipments"`
+	Groups    []*TransitGroup `json:"groups"`
+}
+
+// NewTransitStats computes the transit times of the delivered shipments in store. The origin of a shipment is the
+// location of its first scan, and its destination the location of its last update; shipments without a first scan or
+// a delivery date-time in RFC 3339 are left out.
+func NewTransitStats(ctx context.Context, store Store) (*TransitStats, error) {
+	shipments, err := listShipments(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	s := &TransitStats{Shipments: []Transit{}, Groups: []*TransitGroup{}}
+	groups := map[[2]string]*TransitGroup{}
+	group := func(carrier Carrier, lane string) *TransitGroup {
+		g := groups[[2]string{string(carrier), lane}]
+		if g == nil {
+			g = &TransitGroup{Carrier: carrier, Lane: lane}
+			groups[[2]string{string(carrier), lane}] = g
+			s.Groups = append(s.Groups, g)
+		}
+		return g
+	}
+	for _, sh := range shipments {
+		if !sh.Result.Delivered {
+			continue
+		}
+		events, err := store.Events(ctx, sh.Key)
+		if err != nil {
+			return nil, err
+		}
+		t, ok := transitOf(sh, events)
+		if !ok {
+			continue
+		}
+		s.Shipments = append(s.Shipments, t)
+		group(t.Carrier, "").add(t)
+		group(t.Carrier, t.Lane).add(t)
+	}
+	for _, g := range s.Groups {
+		g.finish()
+	}
+	sort.SliceStable(s.Groups, func(i, j int) bool {
+		a, b := s.Groups[i], s.Groups[j]
+		if a.Carrier != b.Carrier {
+			return a.Carrier < b.Carrier
+		}
+		return a.Lane < b.Lane
+	})
+	return s, nil
+}
+
+// transitOf finds the transit of a delivered shipment from its history of events, most recent first.
+func transitOf(sh Shipment, events []Update) (Transit, bool) {
+	delivered, err := time.Parse(time.RFC3339, sh.Result.DeliveryDateTime)
+	if err != nil || len(events) == 0 {
+		return *new(Transit), false
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if IsPreTransit(events[i].Status) {
+			continue
+		}
+		scanned, err := time.Parse(time.RFC3339, events[i].DateTime)
+		if err != nil || scanned.After(delivered) {
+			return *new(Transit), false
+		}
+		return Transit{
+			Key:       sh.Key,
+			Lane:      laneEnd(events[i].Location) + " → " + laneEnd(events[0].Location),
+			Scanned:   scanned,
+			Delivered: delivered,
+			Days:      delivered.Sub(scanned).Hours() / 24,
+		}, true
+	}
+	return *new(Transit), false
+}
+
+// laneEnd shortens a location to its city and state, e.g. "Brooklyn, NY, United States" to "Brooklyn, NY".
+func laneEnd(location string) string {
+	parts := strings.Split(location, ",")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if loc := strings.Join(parts, ", "); loc != "" {
+		return loc
+	}
+	return "?"
+}
+
+func (s *TransitStats) Rows() [][]string {
+	rows := [][]string{{"CARRIER", "LANE", "SHIPMENTS", "MEAN", "P50", "P90", "P95", "SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+	days := func(d float64) string { return strconv.FormatFloat(d, 'f', 1, 64) }
+	for _, g := range s.Groups {
+		lane := g.Lane
+		if lane == "" {
+			lane = "(all)"
+		}
+		row := []string{string(g.Carrier), lane, strconv.Itoa(g.Shipments), days(g.MeanDays), days(g.P50Days), days(g.P90Days), days(g.P95Days)}
+		for _, n := range g.Weekdays {
+			row = append(row, strconv.Itoa(n))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (s *TransitStats) Summary() string {
+	return fmt.Sprintf("%d delivered shipments; transit times in days from the first scan to delivery\n", len(s.Shipments))
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("shipment not found")
+
+// errUnchanged is returned by the function passed to Store.Update, or to FileStore.update, to leave the store as it
+// was without failing.
+var errUnchanged = errors.New("unchanged")
+
+// Key identifies a shipment.
+type Key struct {
+	Carrier     Carrier `json:"carrier"`
+	TrackingNum string  `json:"trackingNum"`
+}
+
+func (k Key) String() string {
+	return string(k.Carrier) + " " + k.TrackingNum
+}
+
+// Shipment is a tracked parcel and the most recent result for it.
+type Shipment struct {
+	Key
+	Added    time.Time `json:"added"`
+	Checked  time.Time `json:"checked,omitempty"`  // when Result was fetched
+	FirstETA string    `json:"firstEta,omitempty"` // the first estimated delivery date seen
+	Merchant string    `json:"merchant,omitempty"` // the seller, if the shipment was imported from a shipping confirmation
+	OrderID  string    `json:"orderId,omitempty"`
+	Label    string    `json:"label,omitempty"` // what was shipped, e.g. the items ordered
+	Tags     []string  `json:"tags,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	Removed  time.Time `json:"removed,omitempty"`  // when the shipment was moved to the trash, if it is there
+	NextPoll time.Time `json:"nextPoll,omitempty"` // when -watch is due to poll the shipment next
+	Polls    []Poll    `json:"polls,omitempty"`    // the most recent polls by -watch, most recent first
+	Muted    bool      `json:"muted,omitempty"`    // set by mute, which stops notifications about the shipment
+	// notified when the shipment is delivered, e.g. the members of a group buy
+	Recipients []Recipient `json:"recipients,omitempty"`
+	Result     Result      `json:"result"`
+}
+
+// Change describes a modification made to a Store, as reported by Watch.
+type Change struct {
+	Key    Key
+	Events []Update // events appended by AppendEvents; empty for a Put
+}
+
+// Store persists shipments and their histories of tracking events. Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key Key) (Shipment, error)
+	Put(ctx context.Context, s Shipment) error
+	ListShipments(ctx context.Context) ([]Shipment, error)
+	// Update changes the shipment under key in a single step, which other processes sharing the store can't interleave
+	// with: fn is called with the shipment, or with a new one with only its Key set if exists is false, and may modify
+	// it and return events for its history. Unless fn fails, the shipment is saved, and the events that were not
+	// already in its history are added and returned.
+	Update(ctx context.Context, key Key, fn func(sh *Shipment, exists bool) ([]Update, error)) ([]Update, error)
+	// AppendEvents adds the events that are not already in the shipment's history and returns them.
+	AppendEvents(ctx context.Context, key Key, events []Update) ([]Update, error)
+	// Events returns the shipment's history, most recent first. The histories of archived shipments are kept.
+	Events(ctx context.Context, key Key) ([]Update, error)
+	// Archive moves the shipments delivered before t from the shipments listed by ListShipments and returned by Get
+	// to the archive, or deletes them if purge is set, and returns their keys.
+	Archive(ctx context.Context, t time.Time, purge bool) ([]Key, error)
+	ArchivedShipments(ctx context.Context) ([]Shipment, error)
+	// Remove moves the shipments under keys, with their histories, to the trash, stamped with t, and returns the keys
+	// of those found. The archived shipment under a key is removed if there's no other. Removed shipments are kept
+	// until PurgeRemoved, but aren't listed, returned, or given events otherwise.
+	Remove(ctx context.Context, t time.Time, keys ...Key) ([]Key, error)
+	// Restore moves the shipments under keys out of the trash and returns the keys of those found. A shipment that has
+	// been tracked again since it was removed keeps its new entry, and the removed history is added to it.
+	Restore(ctx context.Context, keys ...Key) ([]Key, error)
+	RemovedShipments(ctx context.Context) ([]Shipment, error)
+	// PurgeRemoved deletes the shipments removed before t for good, and returns their keys.
+	PurgeRemoved(ctx context.Context, t time.Time) ([]Key, error)
+	// Watch reports changes made to the store until ctx is done.
+	Watch(ctx context.Context) (<-chan Change, error)
+}
+
+// MemStore is a Store that keeps everything in memory.
+type MemStore struct {
+	mu        sync.Mutex
+	shipments map[Key]Shipment
+	archived  map[Key]Shipment
+	removed   map[Key]Shipment
+	events    map[Key][]Update
+	watchers  map[chan Change]struct{}
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		shipments: make(map[Key]Shipment),
+		archived:  make(map[Key]Shipment),
+		removed:   make(map[Key]Shipment),
+		events:    make(map[Key][]Update),
+		watchers:  make(map[chan Change]struct{}),
+	}
+}
+
+func (m *MemStore) Get(ctx context.Context, key Key) (Shipment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.shipments[key]
+	if !ok {
+		return *new(Shipment), ErrNotFound
+	}
+	return s, nil
+}
+
+func (m *MemStore) Put(ctx context.Context, s Shipment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shipments[s.Key] = s
+	m.notify(Change{Key: s.Key})
+	return nil
+}
+
+func (m *MemStore) ListShipments(ctx context.Context) ([]Shipment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Shipment, 0, len(m.shipments))
+	for _, s := range m.shipments {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, nil
+}
+
+func (m *MemStore) Update(ctx context.Context, key Key, fn func(sh *Shipment, exists bool) ([]Update, error)) ([]Update, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, exists := m.shipments[key]
+	if !exists {
+		s = Shipment{Key: key}
+	}
+	events, err := fn(&s, exists)
+	if errors.Is(err, errUnchanged) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.Key = key
+	m.shipments[key] = s
+	var added []Update
+	m.events[key], added = mergeEvents(m.events[key], events)
+	m.notify(Change{Key: key, Events: added})
+	return added, nil
+}
+
+func (m *MemStore) AppendEvents(ctx context.Context, key Key, events []Update) ([]Update, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.shipments[key]; !ok {
+		return nil, ErrNotFound
+	}
+	var added []Update
+	m.events[key], added = mergeEvents(m.events[key], events)
+	if len(added) > 0 {
+		m.notify(Change{Key: key, Events: added})
+	}
+	return added, nil
+}
+
+func (m *MemStore) Events(ctx context.Context, key Key) ([]Update, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, active := m.shipments[key]
+	_, archived := m.archived[key]
+	if !active && !archived {
+		return nil, ErrNotFound
+	}
+	return append([]Update(nil), m.events[key]...), nil
+}
+
+func (m *MemStore) Archive(ctx context.Context, t time.Time, purge bool) ([]Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []Key
+	for key, s := range m.shipments {
+		if at, ok := deliveredAt(s); ok && at.Before(t) {
+			keys = append(keys, key)
+			delete(m.shipments, key)
+			if purge {
+				delete(m.events, key)
+			} else {
+				m.archived[key] = s
+			}
+			m.notify(Change{Key: key})
+		}
+	}
+	return keys, nil
+}
+
+func (m *MemStore) ArchivedShipments(ctx context.Context) ([]Shipment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Shipment, 0, len(m.archived))
+	for _, s := range m.archived {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, nil
+}
+
+func (m *MemStore) Remove(ctx context.Context, t time.Time, keys ...Key) ([]Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []Key
+	for _, key := range keys {
+		from := m.shipments
+		s, ok := from[key]
+		if !ok {
+			from = m.archived
+			if s, ok = from[key]; !ok {
+				continue
+			}
+		}
+		delete(from, key)
+		s.Removed = t
+		m.removed[key] = s
+		removed = append(removed, key)
+		m.notify(Change{Key: key})
+	}
+	return removed, nil
+}
+
+func (m *MemStore) Restore(ctx context.Context, keys ...Key) ([]Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var restored []Key
+	for _, key := range keys {
+		s, ok := m.removed[key]
+		if !ok {
+			continue
+		}
+		delete(m.removed, key)
+		// the history is kept under the key, and so already belongs to a shipment tracked again
+		if _, ok = m.shipments[key]; !ok {
+			s.Removed = *new(time.Time)
+			m.shipments[key] = s
+		}
+		restored = append(restored, key)
+		m.notify(Change{Key: key})
+	}
+	return restored, nil
+}
+
+func (m *MemStore) RemovedShipments(ctx context.Context) ([]Shipment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Shipment, 0, len(m.removed))
+	for _, s := range m.removed {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Added.Before(list[j].Added)
+	})
+	return list, nil
+}
+
+func (m *MemStore) PurgeRemoved(ctx context.Context, t time.Time) ([]Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []Key
+	for key, s := range m.removed {
+		if !s.Removed.Before(t) {
+			continue
+		}
+		keys = append(keys, key)
+		delete(m.removed, key)
+		_, active := m.shipments[key]
+		_, archived := m.archived[key]
+		if !active && !archived {
+			delete(m.events, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MemStore) Watch(ctx context.Context) (<-chan Change, error) {
+	ch := make(chan Change, 16)
+	m.mu.Lock()
+	m.watchers[ch] = struct{}{}
+	m.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.watchers, ch)
+		m.mu.Unlock()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// notify sends c to every watcher, dropping it for watchers that have fallen behind. m.mu must be held.
+func (m *MemStore) notify(c Change) {
+	for ch := range m.watchers {
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+}
+
+// History is where results are recorded, if anywhere.
+var History Store
+
+// RecordResult saves res as the latest result for its shipment, adding the shipment if it is new, and returns the
+// shipment as it was before and the updates that weren't already in its history. The shipment is read and written in a
+// single Update, so that processes sharing the store don't lose each other's changes. If res has been delivered and an
+// estimated delivery date was recorded for it earlier, RecordResult also sets its ETAAccuracyDays. The tags of res are
+// added to the shipment's, and res is given all of them; likewise, the label and note of res replace the shipment's if
+// they are set, and res is given the shipment's otherwise. The recipients of res are merged into the shipment's in
+// the same way as by -recipient, and res is muted if the shipment is.
+func RecordResult(ctx context.Context, store Store, res *Result, now time.Time) (Shipment, []Update, error) {
+	var prev Shipment
+	key := Key{Carrier: res.Carrier, TrackingNum: res.TrackingNum}
+	added, err := store.Update(ctx, key, func(sh *Shipment, exists bool) ([]Update, error) {
+		prev = *sh
+		if !exists {
+			sh.Added = now
+		}
+		recordResult(sh, res, now)
+		return res.Updates, nil
+	})
+	return prev, added, err
+}
+
+// recordResult merges res into sh, as described for RecordResult.
+func recordResult(sh *Shipment, res *Result, now time.Time) {
+	sh.Checked = now
+	if sh.FirstETA == "" && !res.Delivered {
+		sh.FirstETA = res.DeliveryDateTime
+	}
+	if res.Delivered {
+		if days, ok := ETAAccuracyDays(sh.FirstETA, res.DeliveryDateTime); ok {
+			res.ETAAccuracyDays = &days
+		}
+	}
+	sh.Tags = mergeTags(sh.Tags, res.Tags)
+	res.Tags = sh.Tags
+	if res.Label != "" {
+		sh.Label = res.Label
+	}
+	if res.Note != "" {
+		sh.Note = res.Note
+	}
+	res.Label, res.Note = sh.Label, sh.Note
+	sh.Recipients = mergeRecipients(sh.Recipients, res.recipients)
+	res.recipients = sh.Recipients
+	res.muted = sh.Muted
+	sh.Result = *res
+}
+
+// mergeEvents adds the events that are not already in history, keeping the history sorted most recent first, and
+// returns the merged history and the added events.
+func mergeEvents(history, events []Update) ([]Update, []Update) {
+	// updates are identified by their source fields, not the fields that parcel derives from them
+	type id struct{ dateTime, location, status string }
+	seen := make(map[id]bool, len(history))
+	for _, u := range history {
+		seen[id{u.DateTime, u.Location, u.Status}] = true
+	}
+	var added []Update
+	for _, u := range events {
+		if k := (id{u.DateTime, u.Location, u.Status}); !seen[k] {
+			seen[k] = true
+			added = append(added, u)
+		}
+	}
+	if len(added) == 0 {
+		return history, nil
+	}
+	merged := append(append([]Update(nil), history...), added...)
+	// RFC 3339 date-times with the same offset sort correctly as strings
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].DateTime > merged[j].DateTime
+	})
+	return merged, added
+}
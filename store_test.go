@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	for name, store := range map[string]Store{
+		"mem":  NewMemStore(),
+		"file": NewFileStore(filepath.Join(t.TempDir(), "store.json")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			key := Key{Carrier: UPS, TrackingNum: "1Z999AA10123456784"}
+			other := Key{Carrier: USPS, TrackingNum: "9400100000000000000000"}
+			events := []Update{{DateTime: "2024-01-01T10:00:00Z", Status: "Departed"}}
+			for _, k := range []Key{key, other} {
+				if _, err := store.Update(ctx, k, func(sh *Shipment, exists bool) ([]Update, error) {
+					sh.Added = now
+					return events, nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if removed, err := store.Remove(ctx, now, key, Key{Carrier: DHL, TrackingNum: "1"}); err != nil ||
+				len(removed) != 1 || removed[0] != key {
+				t.Fatalf("Remove: %v, %v", removed, err)
+			}
+			if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get of a removed shipment: %v, want ErrNotFound", err)
+			}
+			if list, _ := store.ListShipments(ctx); len(list) != 1 || list[0].Key != other {
+				t.Errorf("listed %v, want only %v", list, other)
+			}
+			if list, _ := store.RemovedShipments(ctx); len(list) != 1 || list[0].Key != key || !list[0].Removed.Equal(now) {
+				t.Errorf("removed %v, want %v removed at %v", list, key, now)
+			}
+
+			// nothing has been in the trash long enough to be purged
+			if purged, err := store.PurgeRemoved(ctx, now.Add(-time.Hour)); err != nil || len(purged) != 0 {
+				t.Errorf("PurgeRemoved: %v, %v; want none", purged, err)
+			}
+			if restored, err := store.Restore(ctx, key); err != nil || len(restored) != 1 {
+				t.Fatalf("Restore: %v, %v", restored, err)
+			}
+			if sh, err := store.Get(ctx, key); err != nil || !sh.Removed.IsZero() {
+				t.Errorf("Get of a restored shipment: %+v, %v", sh, err)
+			}
+			if got, _ := store.Events(ctx, key); len(got) != 1 {
+				t.Errorf("restored history %v, want %v", got, events)
+			}
+
+			store.Remove(ctx, now, key)
+			if purged, err := store.PurgeRemoved(ctx, now.Add(time.Second)); err != nil || len(purged) != 1 {
+				t.Errorf("PurgeRemoved: %v, %v; want %v", purged, err, key)
+			}
+			if restored, _ := store.Restore(ctx, key); len(restored) != 0 {
+				t.Errorf("restored a purged shipment")
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows && !plan9
+
+package main
+
+import "log/syslog"
+
+func syslogOutput() (func(p Priority, msg string) error, error) {
+	w, err := syslog.New(syslog.LOG_USER|syslog.LOG_INFO, "parcel")
+	if err != nil {
+		return nil, err
+	}
+	return func(p Priority, msg string) error {
+		switch p {
+		case PRI_ERR:
+			return w.Err(msg)
+		case PRI_WARNING:
+			return w.Warning(msg)
+		case PRI_INFO:
+			return w.Info(msg)
+		}
+		return w.Debug(msg)
+	}, nil
+}
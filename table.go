@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ANSI_RESET  = "\x1b[0m"
+	ANSI_BOLD   = "\x1b[1m"
+	ANSI_RED    = "\x1b[31m"
+	ANSI_GREEN  = "\x1b[32m"
+	ANSI_YELLOW = "\x1b[33m"
+)
+
+// words that mark a status as a delivery exception
+var exceptionWords = []string{"exception", "delay", "failed", "undeliverable", "returned", "refused", "damaged", "held"}
+
+// IsTerminal reports whether f is a character device, e.g. an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func IsException(status string) bool {
+	status = strings.ToLower(status)
+	for _, w := range exceptionWords {
+		if strings.Contains(status, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalTable renders res as a summary line followed by an aligned table of its updates, fitted to width columns
+// (0 means unlimited) and colored if color is set.
+func MarshalTable(res Result, width int, color bool) []byte {
+	b := new(strings.Builder)
+
+	b.WriteString(paint(fit(Summary(res), width), ANSI_BOLD+statusColor(res.Delivered, res.Updates), color) + "\n")
+	if len(res.Updates) == 0 {
+		return []byte(b.String())
+	}
+
+	rows := [][3]string{{"DATE", "LOCATION", "STATUS"}}
+	for _, u := range res.Updates {
+		rows = append(rows, [3]string{textTime(u.DateTime), u.Location, u.Status})
+	}
+	var widths [3]int
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	// give up space from the location column, then the status column, until the table fits
+	if width > 0 {
+		const gaps = 4
+		for i, least := range [3]int{1: 12, 2: len("STATUS")} {
+			over := widths[0] + widths[1] + widths[2] + gaps - width
+			if i == 0 || over <= 0 {
+				continue
+			}
+			widths[i] = max(widths[i]-over, min(widths[i], least))
+		}
+	}
+
+	for i, row := range rows {
+		line := pad(fit(row[0], widths[0]), widths[0]) + "  " +
+			pad(fit(row[1], widths[1]), widths[1]) + "  " +
+			fit(row[2], widths[2])
+		switch {
+		case i == 0:
+			line = paint(line, ANSI_BOLD, color)
+		case IsException(row[2]):
+			line = paint(line, ANSI_RED, color)
+		case i == 1 && res.Delivered:
+			line = paint(line, ANSI_GREEN, color)
+		}
+		b.WriteString(line + "\n")
+	}
+	return []byte(b.String())
+}
+
+func statusColor(delivered bool, updates []Update) string {
+	switch {
+	case delivered:
+		return ANSI_GREEN
+	case len(updates) > 0 && IsException(updates[0].Status):
+		return ANSI_RED
+	}
+	return ANSI_YELLOW
+}
+
+func paint(s, code string, color bool) string {
+	if !color {
+		return s
+	}
+	return code + s + ANSI_RESET
+}
+
+// fit truncates s to width runes, marking the truncation with an ellipsis. A width of 0 leaves s unchanged.
+func fit(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func columnsEnv() int {
+	n, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return n
+}
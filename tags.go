@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var ErrTag = errors.New("invalid tag")
+
+// tagsFlag collects repeated -tag flags. A tag is lowercased, and a comma-separated list is read as several tags.
+type tagsFlag []string
+
+func (t *tagsFlag) String() string {
+	return strings.Join(*t, ", ")
+}
+
+func (t *tagsFlag) Set(v string) error {
+	for _, tag := range strings.Split(v, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || strings.ContainsAny(tag, " \t\n") {
+			return ErrTag
+		}
+		*t = mergeTags(*t, []string{tag})
+	}
+	return nil
+}
+
+// mergeTags returns the union of a and b, sorted.
+func mergeTags(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	out := append(slices.Clip(a), b...)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// hasTags reports whether have includes every tag in want.
+func hasTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
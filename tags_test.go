@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		a, b, want []string
+	}{
+		{nil, nil, nil},
+		{[]string{"work"}, nil, []string{"work"}},
+		{nil, []string{"gift", "books"}, []string{"books", "gift"}},
+		{[]string{"gift", "work"}, []string{"books", "work"}, []string{"books", "gift", "work"}},
+		{[]string{"a"}, []string{"a", "a"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		a := append([]string(nil), tt.a...)
+		if got := mergeTags(a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeTags(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+		// a is left alone, since it is often a shipment's tags
+		if !reflect.DeepEqual(a, tt.a) {
+			t.Errorf("mergeTags(%q, %q) modified its first argument to %q", tt.a, tt.b, a)
+		}
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	tests := []struct {
+		have, want []string
+		ok         bool
+	}{
+		{nil, nil, true},
+		{[]string{"gift"}, nil, true},
+		{nil, []string{"gift"}, false},
+		{[]string{"books", "gift", "work"}, []string{"work", "gift"}, true},
+		{[]string{"books", "gift"}, []string{"gift", "work"}, false},
+	}
+	for _, tt := range tests {
+		if ok := hasTags(tt.have, tt.want); ok != tt.ok {
+			t.Errorf("hasTags(%q, %q) = %t, want %t", tt.have, tt.want, ok, tt.ok)
+		}
+	}
+}
+
+func TestTagsFlag(t *testing.T) {
+	var tags tagsFlag
+	for _, v := range []string{"Work", " gift , books", "work"} {
+		if err := tags.Set(v); err != nil {
+			t.Fatalf("Set(%q): %v", v, err)
+		}
+	}
+	if want := []string{"books", "gift", "work"}; !reflect.DeepEqual([]string(tags), want) {
+		t.Errorf("tags %q, want %q", tags, want)
+	}
+	for _, v := range []string{"", "a,,b", "two words"} {
+		if err := tags.Set(v); !errors.Is(err, ErrTag) {
+			t.Errorf("Set(%q): %v, want ErrTag", v, err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin)
+
+package main
+
+import "os"
+
+// TerminalWidth returns the value of $COLUMNS, or 0 if it is unset; the terminal itself is not queried on this platform.
+func TerminalWidth(f *os.File) int {
+	return columnsEnv()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const TEXT_TIME = "Mon Jan 2 3:04 PM"
+
+// MarshalText renders res as a one-line summary followed by a brief timeline of its updates, most recent first.
+func MarshalText(res Result) []byte {
+	b := new(strings.Builder)
+	b.WriteString(Summary(res))
+	if len(res.Tags) > 0 {
+		b.WriteString(" [" + strings.Join(res.Tags, ", ") + "]")
+	}
+	b.WriteString("\n")
+	for _, u := range res.Updates {
+		b.WriteString("  " + textTime(u.DateTime) + "  " + textUpdate(u) + "\n")
+	}
+	return []byte(b.String())
+}
+
+// Title identifies the shipment of res by its label, if it has one, and its carrier and tracking number.
+func Title(res Result) string {
+	id := strings.TrimSpace(string(res.Carrier) + " " + res.TrackingNum)
+	switch {
+	case res.Label == "":
+		return id
+	case id == "":
+		return res.Label
+	}
+	return res.Label + " (" + id + ")"
+}
+
+// Summary describes the current state of res in a single line.
+func Summary(res Result) string {
+	b := new(strings.Builder)
+	if title := Title(res); title != "" {
+		b.WriteString(title + ": ")
+	}
+	switch {
+	case res.Error != nil:
+		b.WriteString("Error: " + res.Error.Message)
+	case res.Delivered:
+		b.WriteString("Delivered " + textTime(res.DeliveryDateTime))
+	case res.State == PRE_TRANSIT:
+		b.WriteString("Label created, not yet scanned")
+		if res.DeliveryDateTime != "" {
+			b.WriteString("; expected " + textDate(res.DeliveryDateTime))
+		}
+	case res.State == STALLED:
+		b.WriteString("Stalled, no updates since " + textDate(res.Updates[0].DateTime))
+	case res.DeliveryDateTime != "":
+		b.WriteString("Expected " + textDate(res.DeliveryDateTime))
+	case len(res.Updates) == 0:
+		b.WriteString("No tracking updates found")
+	default:
+		b.WriteString("In transit")
+	}
+	if res.ETAConfidence == ETA_STALE {
+		b.WriteString(" (stale estimate)")
+	}
+	if len(res.Updates) > 0 {
+		b.WriteString(" — " + textUpdate(res.Updates[0]))
+	}
+	return b.String()
+}
+
+func textUpdate(u Update) string {
+	if u.Location == "" {
+		return u.Status
+	}
+	return u.Status + " (" + u.Location + ")"
+}
+
+// textTime formats RFC 3339 date-times for reading; anything else is returned unchanged.
+func textTime(s string) string {
+	dt, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return dt.Format(TEXT_TIME)
+}
+
+func textDate(s string) string {
+	dt, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return dt.Format("Monday, January 2")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var ErrTemplate = errors.New("the template format requires -template")
+
+// Template is the template used by the template output format.
+var Template *template.Template
+
+// TemplateFuncs are the helper functions available to -template.
+var TemplateFuncs = template.FuncMap{
+	"latest": func(updates []Update) Update {
+		if len(updates) == 0 {
+			return Update{}
+		}
+		return updates[0]
+	},
+	"latestStatus": func(updates []Update) string {
+		if len(updates) == 0 {
+			return ""
+		}
+		return updates[0].Status
+	},
+	"latestLocation": func(updates []Update) string {
+		if len(updates) == 0 {
+			return ""
+		}
+		return updates[0].Location
+	},
+	// date reformats an RFC 3339 date-time with the given Go time layout, leaving unparsable values unchanged
+	"date": func(layout, s string) string {
+		dt, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return s
+		}
+		return dt.Format(layout)
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+}
+
+func ParseTemplate(s string) (*template.Template, error) {
+	return template.New("parcel").Funcs(TemplateFuncs).Parse(s)
+}
+
+// MarshalTemplate executes Template with res, terminating the output with a newline if the template doesn't.
+func MarshalTemplate(res Result) ([]byte, error) {
+	if Template == nil {
+		return nil, ErrTemplate
+	}
+	b := new(bytes.Buffer)
+	if err := Template.Execute(b, res); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cdillond/parcel/internal/bingmock"
+)
+
+// mockEnv points parcel at a bingmock server, records results in a FileStore in a temporary directory, and collects
+// the results passed to the notifiers. Everything is restored when the test ends. The server's clock is stopped, so
+// that polling a shipment again gets the same page.
+type mockEnv struct {
+	store    *FileStore
+	requests atomic.Int32
+
+	mu       sync.Mutex
+	notified []Result
+}
+
+func newMockEnv(t *testing.T) *mockEnv {
+	t.Helper()
+	env := &mockEnv{store: NewFileStore(filepath.Join(t.TempDir(), "store.json"))}
+	now := time.Now()
+	mock := bingmock.Handler{Now: func() time.Time { return now }}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env.requests.Add(1)
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	src, history, notifiers := SourceURL, History, Notifiers
+	t.Cleanup(func() {
+		SourceURL, History, Notifiers = src, history, notifiers
+	})
+	if err := SetSourceURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	History = env.store
+	Notifiers = []Notifier{NotifierFunc(func(ctx context.Context, res Result) error {
+		env.mu.Lock()
+		defer env.mu.Unlock()
+		env.notified = append(env.notified, res)
+		return nil
+	})}
+	return env
+}
+
+// openSinks returns sinks writing ndjson to a temporary file, and a function that closes them and reads the results
+// back.
+func openSinks(t *testing.T) (Sinks, func() []Result) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	sinks, err := OpenSinks([]SinkSpec{{Format: string(NDJSON), Path: path}}, NDJSON, true, false, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sinks, func() []Result {
+		t.Helper()
+		if err := sinks.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var results []Result
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var res Result
+			if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, res)
+		}
+		return results
+	}
+}
+
+// checkStored checks that the store holds the shipment for res, with its updates as the shipment's history.
+func (env *mockEnv) checkStored(t *testing.T, res Result) {
+	t.Helper()
+	ctx := context.Background()
+	key := Key{Carrier: res.Carrier, TrackingNum: res.TrackingNum}
+	sh, err := env.store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get(%v): %v", key, err)
+	}
+	if sh.Result.State != res.State || sh.Result.Delivered != res.Delivered {
+		t.Errorf("stored %s: state %s, delivered %t; want %s, %t", key.TrackingNum, sh.Result.State, sh.Result.Delivered, res.State, res.Delivered)
+	}
+	if sh.Added.IsZero() || sh.Checked.IsZero() {
+		t.Errorf("stored %s: added %v, checked %v", key.TrackingNum, sh.Added, sh.Checked)
+	}
+	events, err := env.store.Events(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(res.Updates) {
+		t.Errorf("stored %s: %d events, want %d", key.TrackingNum, len(events), len(res.Updates))
+	}
+}
+
+func (env *mockEnv) notifiedNums() map[string]int {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	nums := map[string]int{}
+	for _, res := range env.notified {
+		nums[res.TrackingNum]++
+	}
+	return nums
+}
+
+func TestTrackMock(t *testing.T) {
+	newMockEnv(t)
+	ctx := context.Background()
+	tests := []struct {
+		num       string
+		state     State
+		delivered bool
+		updates   int
+	}{
+		{"9400DELIVERED0000000000", DELIVERED, true, 7},
+		{"9400OUTFORDELIVERY00000", IN_TRANSIT, false, 6},
+		{"9400INTRANSIT0000000000", IN_TRANSIT, false, 4},
+		{"9400PRETRANSIT000000000", PRE_TRANSIT, false, 1},
+		{"9400NOTFOUND00000000000", NOT_FOUND, false, 0},
+	}
+	for _, tt := range tests {
+		res, err := Track(ctx, tt.num, USPS)
+		if err != nil {
+			t.Errorf("Track(%s): %v", tt.num, err)
+			continue
+		}
+		if res.TrackingNum != tt.num || res.Carrier != USPS {
+			t.Errorf("Track(%s): got %s %s", tt.num, res.Carrier, res.TrackingNum)
+		}
+		if res.State != tt.state || res.Delivered != tt.delivered || len(res.Updates) != tt.updates {
+			t.Errorf("Track(%s): state %s, delivered %t, %d updates; want %s, %t, %d", tt.num, res.State, res.Delivered,
+				len(res.Updates), tt.state, tt.delivered, tt.updates)
+		}
+		if tt.updates > 0 {
+			if _, err := time.Parse(time.RFC3339, res.Updates[0].DateTime); err != nil {
+				t.Errorf("Track(%s): update date-time: %v", tt.num, err)
+			}
+			if res.DeliveryDateTime == "" {
+				t.Errorf("Track(%s): no delivery date-time", tt.num)
+			}
+		}
+	}
+
+	var se *StatusError
+	if _, err := Track(ctx, "9400RATELIMITED00000000", USPS); !errors.As(err, &se) || se.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Track(rate limited): got %v", err)
+	}
+}
+
+func TestBatchMock(t *testing.T) {
+	env := newMockEnv(t)
+	jobs := []Job{
+		{Num: "9400DELIVERED0000000000", Carrier: USPS},
+		{Num: "1ZINTRANSIT00000000", Carrier: UPS},
+		{Num: "9400RATELIMITED00000000", Carrier: USPS},
+	}
+	sinks, results := openSinks(t)
+	stats, err := RunBatch(context.Background(), jobs, sinks, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Queried != 3 || stats.Delivered != 1 || stats.Failed != 1 {
+		t.Errorf("stats: %+v", stats)
+	}
+
+	byNum := map[string]Result{}
+	for _, res := range results() {
+		byNum[res.TrackingNum] = res
+	}
+	if len(byNum) != len(jobs) {
+		t.Fatalf("got %d results, want %d", len(byNum), len(jobs))
+	}
+	if res := byNum["9400RATELIMITED00000000"]; res.Error == nil {
+		t.Error("rate limited job has no error")
+	}
+	for _, num := range []string{"9400DELIVERED0000000000", "1ZINTRANSIT00000000"} {
+		env.checkStored(t, byNum[num])
+	}
+	// failed lookups are neither recorded nor notified
+	if _, err := env.store.Get(context.Background(), Key{Carrier: USPS, TrackingNum: "9400RATELIMITED00000000"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rate limited job: Get returned %v, want ErrNotFound", err)
+	}
+	if nums := env.notifiedNums(); len(nums) != 2 || nums["9400DELIVERED0000000000"] != 1 || nums["1ZINTRANSIT00000000"] != 1 {
+		t.Errorf("notified: %v", nums)
+	}
+
+	// tracking the same shipments again changes nothing, so nothing more is notified
+	sinks, results = openSinks(t)
+	if _, err = RunBatch(context.Background(), jobs[:2], sinks, 1); err != nil {
+		t.Fatal(err)
+	}
+	results()
+	if nums := env.notifiedNums(); nums["9400DELIVERED0000000000"] != 1 || nums["1ZINTRANSIT00000000"] != 1 {
+		t.Errorf("notified again: %v", nums)
+	}
+}
+
+func TestWatchMock(t *testing.T) {
+	env := newMockEnv(t)
+	// a delivered shipment is polled once, after which Watch returns
+	jobs := []Job{{Num: "9400DELIVERED0000000000", Carrier: USPS}}
+	sinks, results := openSinks(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Watch(ctx, jobs, sinks, time.Minute, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Watch didn't return after the shipment was delivered")
+	}
+
+	written := results()
+	if len(written) != 1 || !written[0].Delivered {
+		t.Fatalf("results: %+v", written)
+	}
+	env.checkStored(t, written[0])
+	if nums := env.notifiedNums(); len(nums) != 1 || nums["9400DELIVERED0000000000"] != 1 {
+		t.Errorf("notified: %v", nums)
+	}
+	// the poll is recorded for the schedule command, with no next poll due
+	sh, err := env.store.Get(context.Background(), Key{Carrier: USPS, TrackingNum: "9400DELIVERED0000000000"})
+	if err != nil || len(sh.Polls) != 1 || !sh.Polls[0].Changed || sh.Polls[0].Error != "" || !sh.NextPoll.IsZero() {
+		t.Errorf("polls %+v, next %v, %v; want one successful poll and none due", sh.Polls, sh.NextPoll, err)
+	}
+
+	// with nothing undelivered in the store, there is nothing left to watch
+	jobs, err = undeliveredJobs(env.store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("undelivered jobs: %v", jobs)
+	}
+}
+
+func TestWatchNoStoreMock(t *testing.T) {
+	env := newMockEnv(t)
+	History = nil
+	// an in-transit shipment is polled until ctx is done, getting the same page every time
+	jobs := []Job{{Num: "9400INTRANSIT0000000000", Carrier: USPS}}
+	sinks, results := openSinks(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := Watch(ctx, jobs, sinks, 50*time.Millisecond, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if n := env.requests.Load(); n < 2 {
+		t.Fatalf("polled %d times, want at least 2", n)
+	}
+	if written := results(); len(written) != 1 {
+		t.Errorf("wrote %d results, want 1", len(written))
+	}
+	if nums := env.notifiedNums(); nums["9400INTRANSIT0000000000"] != 1 {
+		t.Errorf("notified %d times, want 1", nums["9400INTRANSIT0000000000"])
+	}
+}
+
+func TestTrackAnyMock(t *testing.T) {
+	src := SourceURL
+	defer func() { SourceURL = src }()
+	// every carrier has no data for the number, but one of them can't be asked
+	var failing Carrier
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Carrier(r.URL.Query().Get("carrier")) == failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		bingmock.Handler{}.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	if err := SetSourceURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	num := "9400NOTFOUND00000000000"
+	order := anyOrder(num)
+
+	failing = order[len(order)-1]
+	var se *StatusError
+	if res, err := trackAny(context.Background(), num); !errors.As(err, &se) || se.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("with %s failing: got %s, %v; want its error", failing, res.State, err)
+	}
+
+	failing = ""
+	res, err := trackAny(context.Background(), num)
+	if err != nil || res.State != NOT_FOUND || res.Carrier != ANY {
+		t.Errorf("got %s %s, %v; want NOT_FOUND for ANY", res.Carrier, res.State, err)
+	}
+}
+
+func TestMutedMock(t *testing.T) {
+	env := newMockEnv(t)
+	ctx := context.Background()
+	muted := Key{Carrier: USPS, TrackingNum: "9400DELIVERED0000000000"}
+	if err := env.store.Put(ctx, Shipment{Key: muted, Added: time.Now(), Muted: true}); err != nil {
+		t.Fatal(err)
+	}
+	// a muted shipment is still recorded, but not notified
+	jobs := []Job{{Num: muted.TrackingNum, Carrier: USPS}, {Num: "1ZINTRANSIT00000000", Carrier: UPS}}
+	sinks, results := openSinks(t)
+	if _, err := RunBatch(ctx, jobs, sinks, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results() {
+		env.checkStored(t, res)
+	}
+	if nums := env.notifiedNums(); len(nums) != 1 || nums["1ZINTRANSIT00000000"] != 1 {
+		t.Errorf("notified: %v", nums)
+	}
+}
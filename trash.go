@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TRASH_KEEP is how long rm keeps removed shipments, by default, before deleting them for good.
+const TRASH_KEEP = 30 * 24 * time.Hour
+
+// runRm implements the rm command, which moves the shipments with the given tracking numbers, or those selected by the
+// flags of shipmentFilter, and their histories, to the trash, from which restore can bring them back until they have
+// been there for longer than -keep.
+func runRm(args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to remove shipments from (default $PARCEL_STORE, or store.json in the user data directory)")
+	keep := fs.Duration("keep", TRASH_KEEP, "how long to keep removed shipments before deleting them for good")
+	filter := new(shipmentFilter)
+	filter.register(fs)
+	fs.Parse(args)
+
+	store, err := openTrash(*path, *keep)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	shipments, err := archivedStore{store}.ListShipments(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := selectKeys(shipments, filter, fs.Args())
+	if err != nil {
+		return err
+	}
+	if keys, err = store.Remove(ctx, time.Now(), keys...); err != nil {
+		return err
+	}
+	info("removed shipments", "count", len(keys))
+	return nil
+}
+
+// runRestore implements the restore command, which brings the shipments with the given tracking numbers back from the
+// trash, or lists the shipments in the trash if no numbers are given.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	path := fs.String("store", "", "`path` of the store to restore shipments to (default $PARCEL_STORE, or store.json in the user data directory)")
+	keep := fs.Duration("keep", TRASH_KEEP, "how long to keep removed shipments before deleting them for good")
+	format := fs.String("format", "text", "output format of the list of removed shipments: text, json, csv, or markdown")
+	fs.Parse(args)
+
+	store, err := openTrash(*path, *keep)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	removed, err := store.RemovedShipments(ctx)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return writeReport(os.Stdout, &RemovedList{Shipments: removed, keep: *keep}, *format)
+	}
+	keys, err := shipmentKeys(removed, fs.Args())
+	if err != nil {
+		return err
+	}
+	if keys, err = store.Restore(ctx, keys...); err != nil {
+		return err
+	}
+	info("restored shipments", "count", len(keys))
+	return nil
+}
+
+// openTrash opens the store at path, or the default store, and deletes the shipments that have been in its trash for
+// longer than keep.
+func openTrash(path string, keep time.Duration) (Store, error) {
+	p, err := StorePath(path)
+	if err != nil {
+		return nil, err
+	}
+	store := NewFileStore(p)
+	keys, err := store.PurgeRemoved(context.Background(), time.Now().Add(-keep))
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		info("purged removed shipments", "count", len(keys))
+	}
+	return store, nil
+}
+
+// selectKeys returns the keys of the shipments in list that are matched by filter, if it is active, and that have the
+// tracking numbers nums, if any are given. At least one of them is required, so that a command can't be applied to
+// every shipment by mistake.
+func selectKeys(list []Shipment, filter *shipmentFilter, nums []string) ([]Key, error) {
+	if !filter.active() && len(nums) == 0 {
+		fatalWith(EXIT_USAGE, ErrArgs.Error())
+	}
+	if filter.active() {
+		if err := filter.validate(); err != nil {
+			return nil, err
+		}
+		list = slices.DeleteFunc(list, func(sh Shipment) bool { return !filter.match(sh) })
+	}
+	if len(nums) > 0 {
+		return shipmentKeys(list, nums)
+	}
+	keys := make([]Key, len(list))
+	for i, sh := range list {
+		keys[i] = sh.Key
+	}
+	return keys, nil
+}
+
+// shipmentKeys returns the keys of the shipments with the tracking numbers nums, which are matched ignoring case and
+// spaces. Every number must match.
+func shipmentKeys(shipments []Shipment, nums []string) ([]Key, error) {
+	var keys []Key
+	for _, num := range nums {
+		num = strings.ReplaceAll(num, " ", "")
+		found := false
+		for _, sh := range shipments {
+			if strings.EqualFold(sh.TrackingNum, num) {
+				keys = append(keys, sh.Key)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, num)
+		}
+	}
+	return keys, nil
+}
+
+// RemovedList is the list of shipments in the trash.
+type RemovedList struct {
+	Shipments []Shipment `json:"shipments"`
+
+	keep time.Duration
+}
+
+func (l *RemovedList) Rows() [][]string {
+	rows := [][]string{{"CARRIER", "TRACKING NUMBER", "LABEL", "STATE", "REMOVED", "PURGED"}}
+	for _, sh := range l.Shipments {
+		rows = append(rows, []string{
+			string(sh.Carrier),
+			sh.TrackingNum,
+			sh.Label,
+			string(sh.Result.State),
+			sh.Removed.Local().Format(time.DateOnly),
+			sh.Removed.Add(l.keep).Local().Format(time.DateOnly),
+		})
+	}
+	return rows
+}
+
+func (l *RemovedList) Summary() string {
+	if len(l.Shipments) == 1 {
+		return "1 removed shipment\n"
+	}
+	return fmt.Sprintf("%d removed shipments\n", len(l.Shipments))
+}
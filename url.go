@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrURL = errors.New("invalid source URL")
+
+// SourceURL is the template for the tracking page URL; see SetSourceURL.
+var SourceURL = URL
+
+// SetSourceURL sets the URL that tracking pages are fetched from. s is either a template containing the placeholders
+// {num} and {carrier}, or a base URL (e.g. http://localhost:8080 for a mock upstream, or the address of a caching
+// proxy) to which Bing's path and query are appended.
+func SetSourceURL(s string) error {
+	src, err := ParseSourceURL(s)
+	if err != nil {
+		return err
+	}
+	SourceURL = src
+	return nil
+}
+
+// ParseSourceURL converts s, in either of the forms accepted by SetSourceURL, to a format string for sourceURL.
+func ParseSourceURL(s string) (string, error) {
+	u, err := url.Parse(strings.NewReplacer("{num}", "num", "{carrier}", "carrier").Replace(s))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %s", ErrURL, s)
+	}
+	if strings.Contains(s, "{num}") {
+		return strings.NewReplacer("%", "%%", "{num}", "%[1]s", "{carrier}", "%[2]s").Replace(s), nil
+	}
+	return strings.TrimSuffix(strings.ReplaceAll(s, "%", "%%"), "/") + "/packagetrackingv2?packNum=%s&carrier=%s", nil
+}
+
+// TrackingURL returns the URL of the tracking page for num.
+func TrackingURL(num string, carrier Carrier) string {
+	return sourceURL(SourceURL, num, carrier)
+}
+
+func sourceURL(src, num string, carrier Carrier) string {
+	return fmt.Sprintf(src, url.QueryEscape(num), url.QueryEscape(string(carrier)))
+}
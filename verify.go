@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// VerifyURL is the format string of a second, independent source to cross-check results against, or empty. See
+// ParseSourceURL.
+var VerifyURL string
+
+// trackVerified tracks num with both SourceURL and VerifyURL in parallel, and returns the result from SourceURL with
+// any disagreement between the two recorded in its Discrepancies. The pages are compared as parsed, and only the
+// result returned is finished. An error from either source is an error, since a result that can't be checked can't be
+// trusted.
+func trackVerified(ctx context.Context, num string, carrier Carrier) (Result, error) {
+	type outcome struct {
+		res Result
+		err error
+	}
+	ch := make(chan outcome, 1)
+	go func() {
+		res, err := trackFrom(ctx, VerifyURL, num, carrier)
+		ch <- outcome{res, err}
+	}()
+	res, err := trackFrom(ctx, SourceURL, num, carrier)
+	other := <-ch
+	if err != nil {
+		return *new(Result), err
+	}
+	if other.err != nil {
+		return *new(Result), fmt.Errorf("verifying: %w", other.err)
+	}
+	res.Discrepancies = Discrepancies(res, other.res)
+	for _, d := range res.Discrepancies {
+		warn("sources disagree", "num", num, "discrepancy", d)
+	}
+	finishResult(ctx, &res)
+	return res, nil
+}
+
+// Discrepancies lists the ways in which res, from the primary source, and other, from the verifying source, disagree
+// about the delivery of a shipment.
+func Discrepancies(res, other Result) []string {
+	var d []string
+	switch {
+	case res.Delivered && !other.Delivered:
+		d = append(d, "delivered according to the primary source only")
+	case !res.Delivered && other.Delivered:
+		d = append(d, "delivered according to the verifying source only")
+	case res.Delivered && !sameTime(res.DeliveryDateTime, other.DeliveryDateTime):
+		d = append(d, fmt.Sprintf("delivery times differ: %s and %s", res.DeliveryDateTime, other.DeliveryDateTime))
+	}
+	if res.State != other.State && !(res.Delivered || other.Delivered) {
+		d = append(d, fmt.Sprintf("states differ: %s and %s", res.State, other.State))
+	}
+	if len(res.Updates) > 0 && len(other.Updates) > 0 {
+		a, b := res.Updates[0], other.Updates[0]
+		if a.Status == b.Status && !sameTime(a.DateTime, b.DateTime) {
+			d = append(d, fmt.Sprintf("latest update %q is dated %s and %s", a.Status, a.DateTime, b.DateTime))
+		}
+	}
+	return d
+}
+
+// sameTime reports whether a and b are the same date-time, comparing them as strings if either isn't RFC 3339.
+func sameTime(a, b string) bool {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ta.Equal(tb)
+}
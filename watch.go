@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// PollInterval returns how long to wait before polling the shipment of res again, between min and max: min once it
+// is out for delivery, max while it has not been scanned or can't be found, and otherwise a twelfth of the time left
+// until its estimated delivery date, so that polls become more frequent as delivery approaches.
+func PollInterval(res Result, now time.Time, min, max time.Duration) time.Duration {
+	clamp := func(d time.Duration) time.Duration {
+		if d < min {
+			return min
+		}
+		if d > max {
+			return max
+		}
+		return d
+	}
+	switch {
+	case outForDelivery(res):
+		return min
+	case res.Error != nil:
+		return clamp(time.Hour)
+	case res.State == PRE_TRANSIT, res.State == NOT_FOUND:
+		return max
+	}
+	if eta, err := time.Parse(time.RFC3339, res.DeliveryDateTime); err == nil {
+		return clamp(eta.Sub(now) / 12)
+	}
+	return clamp(time.Hour)
+}
+
+func outForDelivery(res Result) bool {
+	return !res.Delivered && len(res.Updates) > 0 && strings.Contains(strings.ToLower(res.Updates[0].Status), "out for delivery")
+}
+
+// changed reports whether cur differs from prev in any way that is worth reporting.
+func changed(prev, cur Result) bool {
+	if prev.Delivered != cur.Delivered || prev.State != cur.State || prev.DeliveryDateTime != cur.DeliveryDateTime ||
+		len(prev.Updates) != len(cur.Updates) || (prev.Error == nil) != (cur.Error == nil) {
+		return true
+	}
+	return len(cur.Updates) > 0 && cur.Updates[0].DateTime != prev.Updates[0].DateTime
+}
+
+// Watch polls each job until its shipment has been delivered or ctx is done, writing a result to sinks on the first
+// poll and whenever the shipment changes. Polls are spaced by PollInterval, and recorded in the history along with
+// when the next one is due, for the schedule command. Under systemd, Watch reports when it is ready and stopping, and
+// pings the watchdog for as long as it is watching, so that a hung process gets restarted.
+func Watch(ctx context.Context, jobs []Job, sinks Sinks, min, max time.Duration) error {
+	type watched struct {
+		job  Job
+		last *Result
+		next time.Time
+	}
+	pending := make([]*watched, len(jobs))
+	for i, job := range jobs {
+		pending[i] = &watched{job: job, next: time.Now()}
+	}
+	if interval := WatchdogInterval(); interval > 0 {
+		// ping from a ticker of its own, since a poll can block for longer than the watchdog allows, e.g. on the -rps
+		// limiter or a slow source
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					sdNotify("WATCHDOG=1")
+				}
+			}
+		}()
+	}
+	sdNotify("READY=1")
+	defer sdNotify("STOPPING=1")
+	archived := time.Now()
+
+	for len(pending) > 0 {
+		// poll the shipment that is due first
+		due := 0
+		for i, w := range pending {
+			if w.next.Before(pending[due].next) {
+				due = i
+			}
+		}
+		w := pending[due]
+		timer := time.NewTimer(time.Until(w.next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return sinks.Flush()
+		case <-timer.C:
+		}
+
+		polled := time.Now()
+		res, err := Track(ctx, w.job.Num, w.job.Carrier)
+		if ctx.Err() != nil {
+			return sinks.Flush()
+		}
+		if err != nil {
+			warn("tracking failed", "num", w.job.Num, "err", err)
+			res = FailedResult(w.job.Num, w.job.Carrier, err)
+		}
+		// without a store, this is the only way for onResult to tell a change from a repeat
+		isChange := w.last == nil || changed(*w.last, res)
+		if err == nil {
+			onResult(&res, isChange)
+		}
+		if isChange {
+			info("shipment changed", "num", w.job.Num, "state", res.State)
+			if err = sinks.Write(res); err != nil {
+				return err
+			}
+		}
+		w.last = &res
+		if time.Since(archived) > ARCHIVE_CHECK {
+			archiveDelivered()
+			archived = time.Now()
+		}
+
+		poll := Poll{Time: polled, Changed: isChange}
+		if err != nil {
+			poll.Error = err.Error()
+		}
+		if res.Delivered {
+			recordPoll(res, poll, *new(time.Time))
+			pending = append(pending[:due], pending[due+1:]...)
+			continue
+		}
+		interval := PollInterval(res, time.Now(), min, max)
+		debug("next poll", "num", w.job.Num, "in", interval)
+		w.next = time.Now().Add(interval)
+		recordPoll(res, poll, w.next)
+	}
+	return sinks.Flush()
+}
+
+// undeliveredJobs returns a job for each shipment in store that has not been delivered.
+func undeliveredJobs(store Store) ([]Job, error) {
+	list, err := store.ListShipments(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, sh := range list {
+		if !sh.Result.Delivered {
+			jobs = append(jobs, Job{Num: sh.TrackingNum, Carrier: sh.Carrier})
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookSecret, if set, is the key that webhook payloads are signed with.
+var WebhookSecret []byte
+
+// WebhookNotifier posts each changed result, as JSON, to URL.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, res Result) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return sendSigned(ctx, w.URL, b)
+}
+
+// sendSigned posts a JSON payload to a webhook, signed with WebhookSecret if it is set.
+func sendSigned(ctx context.Context, url string, payload []byte) error {
+	var header http.Header
+	if len(WebhookSecret) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		header = http.Header{
+			HEADER_TIMESTAMP: {ts},
+			HEADER_SIGNATURE: {SignPayload(WebhookSecret, ts, payload)},
+		}
+	}
+	return sendRaw(ctx, http.MethodPost, url, header, "application/json", payload)
+}
+
+// SignPayload computes the X-Parcel-Signature header of a webhook payload: "sha256=" followed by the hex-encoded
+// HMAC-SHA256, keyed with key, of the timestamp sent in X-Parcel-Timestamp and the payload, separated by a newline.
+func SignPayload(key []byte, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts + "\n"))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
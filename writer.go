@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// ResultWriter writes a sequence of results to an output. Streaming formats, and gob, are written as each result
+// arrives; in batch mode, results in the other formats are collected and written as a single document by Flush.
+type ResultWriter struct {
+	w       io.Writer
+	f       Format
+	pretty  bool
+	batch   bool
+	enc     *gob.Encoder
+	results []Result
+
+	// table output settings, for writers attached to a terminal
+	width int
+	color bool
+}
+
+func NewResultWriter(w io.Writer, f Format, pretty, batch bool) *ResultWriter {
+	rw := &ResultWriter{w: w, f: f, pretty: pretty, batch: batch, results: []Result{}}
+	if f == GOB {
+		// a single encoder sends the type information once, so the output is a stream of Results
+		rw.enc = gob.NewEncoder(w)
+	}
+	return rw
+}
+
+func (rw *ResultWriter) Write(res Result) error {
+	switch {
+	case rw.enc != nil:
+		return rw.enc.Encode(res)
+	case rw.batch && !rw.f.Streams():
+		rw.results = append(rw.results, res)
+		return nil
+	}
+	if rw.f == TABLE {
+		_, err := rw.w.Write(MarshalTable(res, rw.width, rw.color))
+		return err
+	}
+	b, err := Marshal(res, rw.f, rw.pretty)
+	if err != nil {
+		return err
+	}
+	_, err = rw.w.Write(b)
+	return err
+}
+
+// Flush writes any collected results.
+func (rw *ResultWriter) Flush() error {
+	if !rw.batch || rw.enc != nil || rw.f.Streams() {
+		return nil
+	}
+	b, err := MarshalBatch(rw.results, rw.f, rw.pretty)
+	if err != nil {
+		return err
+	}
+	rw.results = rw.results[:0]
+	_, err = rw.w.Write(b)
+	return err
+}